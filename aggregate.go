@@ -0,0 +1,236 @@
+package crud
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// AggregateFunc is a function computing a metric over the rows of a group.
+type AggregateFunc string
+
+const (
+	AggregateCount AggregateFunc = "count"
+	AggregateSum   AggregateFunc = "sum"
+	AggregateAvg   AggregateFunc = "avg"
+	AggregateMin   AggregateFunc = "min"
+	AggregateMax   AggregateFunc = "max"
+)
+
+// Metric is an aggregate function over a column. The column is optional for AggregateCount, which counts rows then.
+type Metric struct {
+	Func   AggregateFunc
+	Column string
+}
+
+// Name returns the key of the metric in AggregateRow.Values, e.g. "count" or "avg_code".
+func (m Metric) Name() string {
+	if len(m.Column) == 0 {
+		return string(m.Func)
+	}
+	return string(m.Func) + "_" + m.Column
+}
+
+// AggregateSpec describes an aggregation: the rows are grouped by the GroupBy columns (all together when empty)
+// and the Metrics are computed for each group.
+type AggregateSpec struct {
+	GroupBy []string
+	Metrics []Metric
+}
+
+// AggregateRow holds the group key values of a group, by column name, and its metric values, by metric name.
+// Keys have the type of their field, counts are int64, averages float64 and the other metrics have the type of their field
+// if it is numeric. Values are nil for NULL results.
+type AggregateRow struct {
+	Keys   map[string]any `json:"keys"`
+	Values map[string]any `json:"values"`
+}
+
+// ParseMetrics parses metric specifications such as "count,avg:code,max:created_at".
+func ParseMetrics(metrics string) ([]Metric, error) {
+	result := make([]Metric, 0)
+	for _, m := range strings.Split(metrics, ",") {
+		function, column, _ := strings.Cut(strings.TrimSpace(m), ":")
+		if len(function) == 0 {
+			return nil, invalidFilter("invalid metric '%s'", m)
+		}
+		result = append(result, Metric{Func: AggregateFunc(strings.ToLower(function)), Column: strings.TrimSpace(column)})
+	}
+	return result, nil
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+// newScanTarget returns a pointer to a nil pointer of the given type, so that NULL can be scanned into it.
+func newScanTarget(fieldType reflect.Type) reflect.Value {
+	for fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	return reflect.New(reflect.PointerTo(fieldType))
+}
+
+func scannedValue(target reflect.Value) any {
+	if target.Elem().IsNil() {
+		return nil
+	}
+	return target.Elem().Elem().Interface()
+}
+
+// buildMetric returns the select expression of the given metric and the type its result is scanned into.
+func buildMetric(sch *schema.Schema, metric Metric) (clause.Expr, reflect.Type, error) {
+	alias := clause.Column{Name: metric.Name()}
+	if metric.Func == AggregateCount && len(metric.Column) == 0 {
+		return clause.Expr{SQL: "COUNT(*) AS ?", Vars: []any{alias}}, reflect.TypeOf(int64(0)), nil
+	}
+	field := lookUpColumn(sch, metric.Column)
+	if field == nil {
+		return clause.Expr{}, nil, invalidFilter("unknown metric column '%s'", metric.Column)
+	}
+	column := clause.Column{Name: field.DBName}
+	fieldType := field.FieldType
+	for fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	switch metric.Func {
+	case AggregateCount:
+		return clause.Expr{SQL: "COUNT(?) AS ?", Vars: []any{column, alias}}, reflect.TypeOf(int64(0)), nil
+	case AggregateAvg:
+		if !isNumericKind(fieldType.Kind()) {
+			return clause.Expr{}, nil, invalidFilter("cannot average '%s'", metric.Column)
+		}
+		return clause.Expr{SQL: "AVG(?) AS ?", Vars: []any{column, alias}}, reflect.TypeOf(float64(0)), nil
+	case AggregateSum:
+		if !isNumericKind(fieldType.Kind()) {
+			return clause.Expr{}, nil, invalidFilter("cannot sum '%s'", metric.Column)
+		}
+		return clause.Expr{SQL: "SUM(?) AS ?", Vars: []any{column, alias}}, fieldType, nil
+	case AggregateMin, AggregateMax:
+		resultType := reflect.TypeOf((*any)(nil)).Elem()
+		if isNumericKind(fieldType.Kind()) || fieldType.Kind() == reflect.String {
+			resultType = fieldType
+		}
+		return clause.Expr{SQL: strings.ToUpper(string(metric.Func)) + "(?) AS ?", Vars: []any{column, alias}}, resultType, nil
+	}
+	return clause.Expr{}, nil, invalidFilter("unknown aggregate function '%s'", metric.Func)
+}
+
+// Aggregate groups the entities matching the FindBy conditions of the filter by the columns of the spec
+// and computes its metrics for each group. The rows are sorted by the SortBy of the filter, which may name
+// group columns or metrics, and by the group columns otherwise. Paging applies only if the filter has a Limit.
+func (service *CrudServiceImpl[T, TPublicId]) Aggregate(spec AggregateSpec, filter *DataFilter) ([]AggregateRow, error) {
+	if len(spec.Metrics) == 0 {
+		return nil, invalidFilter("at least one metric is required")
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+
+	selection := make([]string, 0, len(spec.GroupBy)+len(spec.Metrics))
+	vars := make([]any, 0, len(spec.GroupBy)+len(spec.Metrics))
+	groupBy := make([]clause.Column, 0, len(spec.GroupBy))
+	targetTypes := make([]reflect.Type, 0, len(spec.GroupBy)+len(spec.Metrics))
+	names := make(map[string]clause.Column)
+	for _, name := range spec.GroupBy {
+		field := lookUpColumn(sch, name)
+		if field == nil {
+			return nil, invalidFilter("unknown group column '%s'", name)
+		}
+		column := clause.Column{Name: field.DBName}
+		selection = append(selection, "?")
+		vars = append(vars, column)
+		groupBy = append(groupBy, column)
+		targetTypes = append(targetTypes, field.FieldType)
+		names[name] = column
+		names[field.DBName] = column
+	}
+	for _, metric := range spec.Metrics {
+		expr, targetType, err := buildMetric(sch, metric)
+		if err != nil {
+			return nil, err
+		}
+		selection = append(selection, "?")
+		vars = append(vars, expr)
+		targetTypes = append(targetTypes, targetType)
+		names[metric.Name()] = clause.Column{Name: metric.Name()}
+	}
+
+	query := db.Model(new(T))
+	if filter != nil {
+		if filter, err = service.normalizeAggregateFilter(filter); err != nil {
+			return nil, err
+		}
+		if query, err = service.applyFilter(query, filter); err != nil {
+			return nil, err
+		}
+		for _, sortInfo := range filter.SortBy {
+			column, ok := names[sortInfo.Column]
+			if !ok {
+				return nil, invalidFilter("cannot sort by '%s'", sortInfo.Column)
+			}
+			query = query.Order(clause.OrderByColumn{Column: column, Desc: sortInfo.Desc})
+		}
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit).Offset(filter.Page * filter.Limit)
+		}
+	}
+	if len(groupBy) > 0 {
+		query = query.Clauses(clause.GroupBy{Columns: groupBy})
+		if filter == nil || len(filter.SortBy) == 0 {
+			for _, column := range groupBy {
+				query = query.Order(column)
+			}
+		}
+	}
+	rows, err := query.Select(strings.Join(selection, ", "), vars...).Rows()
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	result := make([]AggregateRow, 0)
+	for rows.Next() {
+		targets := make([]any, len(targetTypes))
+		for i, targetType := range targetTypes {
+			targets[i] = newScanTarget(targetType).Interface()
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return nil, fmt.Errorf("failed to read aggregate: %w", err)
+		}
+		row := AggregateRow{Keys: make(map[string]any), Values: make(map[string]any)}
+		for i, column := range groupBy {
+			row.Keys[column.Name] = scannedValue(reflect.ValueOf(targets[i]))
+		}
+		for i, metric := range spec.Metrics {
+			row.Values[metric.Name()] = scannedValue(reflect.ValueOf(targets[len(groupBy)+i]))
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return result, nil
+}
+
+// normalizeAggregateFilter validates the sort specification of the filter and moves it into SortBy,
+// without applying the default page size.
+func (service *CrudServiceImpl[T, TPublicId]) normalizeAggregateFilter(filter *DataFilter) (*DataFilter, error) {
+	if len(filter.Sort) == 0 {
+		return filter, nil
+	}
+	sortInfos, err := ParseSort(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+	normalized := *filter
+	normalized.SortBy = append(append([]SortInfo{}, filter.SortBy...), sortInfos...)
+	normalized.Sort = ""
+	return &normalized, nil
+}
@@ -0,0 +1,67 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate(t *testing.T) {
+	create_and_populate_coded_test_db()
+	// Contacts with codes 0 .. 9 are in Addis Ababa, the others in Nairobi
+	crud_test_db.Exec("UPDATE test_contacts SET phone = CASE WHEN code < 10 THEN 'Addis Ababa' ELSE 'Nairobi' END")
+
+	spec := AggregateSpec{
+		GroupBy: []string{"Phone"},
+		Metrics: []Metric{{Func: AggregateCount}, {Func: AggregateAvg, Column: "code"}, {Func: AggregateMax, Column: "code"},
+			{Func: AggregateSum, Column: "code"}, {Func: AggregateCount, Column: "email"}},
+	}
+	result, err := contactsService.Aggregate(spec, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []AggregateRow{
+		{
+			Keys:   map[string]any{"phone": "Addis Ababa"},
+			Values: map[string]any{"count": int64(10), "avg_code": 4.5, "max_code": 9, "sum_code": 45, "count_email": int64(5)},
+		},
+		{
+			Keys:   map[string]any{"phone": "Nairobi"},
+			Values: map[string]any{"count": int64(20), "avg_code": 19.5, "max_code": 29, "sum_code": 390, "count_email": int64(20)},
+		},
+	}, result)
+
+	result, err = contactsService.Aggregate(spec, &DataFilter{Sort: "count:desc", Limit: 1, FindBy: map[string]any{"code": Gte(5)}})
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "Nairobi", result[0].Keys["phone"])
+
+	result, err = contactsService.Aggregate(AggregateSpec{Metrics: []Metric{{Func: AggregateMin, Column: "full_name"}}}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "Cont-0", result[0].Values["min_full_name"])
+}
+
+func TestAggregateRejectsInvalidSpecs(t *testing.T) {
+	create_and_populate_test_db(3)
+
+	for _, spec := range []AggregateSpec{
+		{},
+		{GroupBy: []string{"city"}, Metrics: []Metric{{Func: AggregateCount}}},
+		{Metrics: []Metric{{Func: AggregateAvg, Column: "full_name"}}},
+		{Metrics: []Metric{{Func: AggregateSum}}},
+		{Metrics: []Metric{{Func: "median", Column: "code"}}},
+	} {
+		_, err := contactsService.Aggregate(spec, nil)
+		assert.True(t, errors.Is(err, ErrInvalidFilter), spec)
+	}
+	_, err := contactsService.Aggregate(AggregateSpec{Metrics: []Metric{{Func: AggregateCount}}}, &DataFilter{Sort: "code"})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
+
+func TestParseMetrics(t *testing.T) {
+	metrics, err := ParseMetrics("count, AVG:code")
+	assert.Nil(t, err)
+	assert.Equal(t, []Metric{{Func: AggregateCount}, {Func: AggregateAvg, Column: "code"}}, metrics)
+
+	_, err = ParseMetrics("count,,avg:code")
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
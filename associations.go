@@ -0,0 +1,235 @@
+package crud
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// AssociationOptions configure a many to many association of the entities, see CrudServiceOptions.AssociationOptions.
+type AssociationOptions struct {
+	// PublicIdColumnName is the public id column of the associated entities, the one of the service by default.
+	PublicIdColumnName string
+	// SortableColumns are the columns (or their JSON names) the associated entities can be sorted by, all the columns by default.
+	SortableColumns []string
+}
+
+// getAssociation returns the many to many relationship of the entity with the given field or JSON name.
+func (service *CrudServiceImpl[T, TPublicId]) getAssociation(name string) (*schema.Relationship, error) {
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	for _, relationship := range sch.Relationships.Many2Many {
+		if strings.EqualFold(relationship.Name, name) || jsonName(relationship.Field) == name {
+			return relationship, nil
+		}
+	}
+	return nil, invalidFilter("unknown many to many association '%s'", name)
+}
+
+// getAssociationOptions returns the options of the given many to many relationship, defaulting its public id column.
+func (service *CrudServiceImpl[T, TPublicId]) getAssociationOptions(relationship *schema.Relationship) AssociationOptions {
+	var result AssociationOptions
+	for name, options := range service._options.AssociationOptions {
+		if strings.EqualFold(relationship.Name, name) || jsonName(relationship.Field) == name {
+			result = options
+			break
+		}
+	}
+	if len(result.PublicIdColumnName) == 0 {
+		result.PublicIdColumnName = service._options.PublicIdColumnName
+	}
+	return result
+}
+
+// findAssociated returns a pointer to the slice of the entities of the given relationship having the given public ids.
+// It fails with ErrNotFound if any of them does not exist.
+func (service *CrudServiceImpl[T, TPublicId]) findAssociated(db *gorm.DB, relationship *schema.Relationship, publicIds []any) (any, error) {
+	publicIdColumnName := service.getAssociationOptions(relationship).PublicIdColumnName
+	publicIdField := lookUpColumn(relationship.FieldSchema, publicIdColumnName)
+	if publicIdField == nil {
+		return nil, fmt.Errorf("public id '%s' is not a column of %s", publicIdColumnName, relationship.FieldSchema.Name)
+	}
+	values := make([]any, 0, len(publicIds))
+	seen := make(map[any]bool)
+	for _, publicId := range publicIds {
+		value, err := coerceValue(publicIdField, publicId)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	result := reflect.New(reflect.SliceOf(relationship.FieldSchema.ModelType))
+	if len(values) == 0 {
+		return result.Interface(), nil
+	}
+	err := db.Model(reflect.New(relationship.FieldSchema.ModelType).Interface()).
+		Where(clause.IN{Column: clause.Column{Name: publicIdField.DBName}, Values: values}).
+		Find(result.Interface()).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if result.Elem().Len() != len(values) {
+		return nil, NewCrudError(ErrNotFound, fmt.Sprintf("some of the %s to associate are not found", relationship.Name))
+	}
+	return result.Interface(), nil
+}
+
+// changeAssociations applies the given change to the association of the entity with the given public id,
+// with the associated entities having the given public ids.
+func (service *CrudServiceImpl[T, TPublicId]) changeAssociations(publicId TPublicId, association string, associatedPublicIds []any,
+	change func(association *gorm.Association, values any) error) error {
+	relationship, err := service.getAssociation(association)
+	if err != nil {
+		return err
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		owner, err := service.WithTx(tx).FindOneByPublicId(publicId)
+		if err != nil {
+			return err
+		}
+		values, err := service.findAssociated(tx, relationship, associatedPublicIds)
+		if err != nil {
+			return err
+		}
+		return change(tx.Model(owner).Association(relationship.Name), values)
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+// Attach associates the entity with the given public id with the entities of the given many to many association
+// having the given public ids. The associated entities are found by the public id column of the association options,
+// the one of the service by default.
+func (service *CrudServiceImpl[T, TPublicId]) Attach(publicId TPublicId, association string, associatedPublicIds ...any) error {
+	return service.changeAssociations(publicId, association, associatedPublicIds, func(a *gorm.Association, values any) error {
+		return a.Append(values)
+	})
+}
+
+// Detach removes the association of the entity with the given public id with the entities having the given public ids,
+// without deleting any of them.
+func (service *CrudServiceImpl[T, TPublicId]) Detach(publicId TPublicId, association string, associatedPublicIds ...any) error {
+	return service.changeAssociations(publicId, association, associatedPublicIds, func(a *gorm.Association, values any) error {
+		if reflect.ValueOf(values).Elem().Len() == 0 {
+			return nil
+		}
+		return a.Delete(values)
+	})
+}
+
+// ReplaceAssociations associates the entity with the given public id with exactly the entities having the given public ids.
+func (service *CrudServiceImpl[T, TPublicId]) ReplaceAssociations(publicId TPublicId, association string, associatedPublicIds ...any) error {
+	return service.changeAssociations(publicId, association, associatedPublicIds, func(a *gorm.Association, values any) error {
+		if reflect.ValueOf(values).Elem().Len() == 0 {
+			return a.Clear()
+		}
+		return a.Replace(values)
+	})
+}
+
+// ListAssociated returns the page of the entities of the given many to many association of the entity with the given public id.
+// The paging, sorting and FindBy conditions of the filter apply to the associated entities,
+// which can be sorted by the SortableColumns of the association options only. See ListAssociatedAs for a typed page.
+func (service *CrudServiceImpl[T, TPublicId]) ListAssociated(publicId TPublicId, association string, filterParam ...*DataFilter) (*PagedList[any], error) {
+	relationship, err := service.getAssociation(association)
+	if err != nil {
+		return nil, err
+	}
+	var filter *DataFilter
+	if len(filterParam) > 0 {
+		filter = filterParam[0]
+	}
+	filter, err = service.normalizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := service.FindOneByPublicId(publicId)
+	if err != nil {
+		return nil, err
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+
+	query := db.Model(owner)
+	if len(filter.FindBy) > 0 {
+		conditions, err := buildFindByConditions(relationship.FieldSchema, filter.FindBy)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(clause.And(conditions...))
+	}
+	orderBy, err := getOrderBy(relationship.FieldSchema, service.getAssociationOptions(relationship).SortableColumns, filter)
+	if err != nil {
+		return nil, err
+	}
+	query = query.Session(&gorm.Session{})
+	orderedQuery := query
+	for _, column := range orderBy {
+		column.Column.Table = relationship.FieldSchema.Table
+		orderedQuery = orderedQuery.Order(column)
+	}
+
+	values := reflect.New(reflect.SliceOf(relationship.FieldSchema.ModelType))
+	err = orderedQuery.
+		Limit(filter.Limit).
+		Offset(filter.Page * filter.Limit).
+		Association(relationship.Name).
+		Find(values.Interface())
+	if err != nil {
+		return nil, translateError(err)
+	}
+	countAssociation := query.Association(relationship.Name)
+	totalCount := countAssociation.Count()
+	if countAssociation.Error != nil {
+		return nil, translateError(countAssociation.Error)
+	}
+	list := make([]any, values.Elem().Len())
+	for i := range list {
+		list[i] = values.Elem().Index(i).Interface()
+	}
+	return NewPagedList(list, int(totalCount), filter), nil
+}
+
+// ListAssociatedAs returns the page of ListAssociated with the associated entities typed as TAssociated,
+// which should be the entity type of the association.
+func ListAssociatedAs[TAssociated any, TPublicId any](service AssociationManager[TPublicId], publicId TPublicId, association string,
+	filter ...*DataFilter) (*PagedList[TAssociated], error) {
+	page, err := service.ListAssociated(publicId, association, filter...)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]TAssociated, len(page.List))
+	for i, entity := range page.List {
+		typed, ok := entity.(TAssociated)
+		if !ok {
+			return nil, fmt.Errorf("association '%s' holds %T, not %T", association, entity, typed)
+		}
+		list[i] = typed
+	}
+	return &PagedList[TAssociated]{
+		List:        list,
+		TotalCount:  page.TotalCount,
+		Page:        page.Page,
+		Limit:       page.Limit,
+		HasNext:     page.HasNext,
+		HasPrevious: page.HasPrevious,
+		TotalPages:  page.TotalPages,
+		Skip:        page.Skip,
+		NextCursor:  page.NextCursor,
+		PrevCursor:  page.PrevCursor,
+		CountExact:  page.CountExact,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func create_and_populate_tagged_test_db() {
+	create_and_populate_test_db(3)
+	crud_test_db.Create(&[]TestTag{{PublicId: "t1", Name: "family"}, {PublicId: "t2", Name: "work"}, {PublicId: "t3", Name: "school"}})
+}
+
+func tagNamesOf(t *testing.T, publicId string) []string {
+	result, err := ListAssociatedAs[TestTag, string](contactsService, publicId, "tags", &DataFilter{Limit: 10, Sort: "name"})
+	assert.Nil(t, err)
+	names := make([]string, len(result.List))
+	for i, tag := range result.List {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+func TestAssociations(t *testing.T) {
+	create_and_populate_tagged_test_db()
+	publicId := crud_test_public_ids[0]
+
+	assert.Nil(t, contactsService.Attach(publicId, "Tags", "t1", "t2"))
+	assert.Nil(t, contactsService.Attach(publicId, "Tags", "t2"))
+	assert.Equal(t, []string{"family", "work"}, tagNamesOf(t, publicId))
+	assert.Empty(t, tagNamesOf(t, crud_test_public_ids[1]))
+
+	assert.Nil(t, contactsService.Detach(publicId, "Tags", "t1"))
+	assert.Equal(t, []string{"work"}, tagNamesOf(t, publicId))
+	count, _ := tagsService.Count()
+	assert.Equal(t, 3, count)
+
+	assert.Nil(t, contactsService.ReplaceAssociations(publicId, "Tags", "t3", "t1"))
+	assert.Equal(t, []string{"family", "school"}, tagNamesOf(t, publicId))
+
+	page, err := contactsService.ListAssociated(publicId, "Tags", &DataFilter{Limit: 1, Page: 1, Sort: "name:desc"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, page.TotalCount)
+	assert.Equal(t, "family", page.List[0].(TestTag).Name)
+
+	page, err = contactsService.ListAssociated(publicId, "Tags", &DataFilter{FindBy: map[string]any{"name": StartsWith("sch")}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, page.TotalCount)
+
+	assert.Nil(t, contactsService.ReplaceAssociations(publicId, "Tags"))
+	assert.Empty(t, tagNamesOf(t, publicId))
+}
+
+func TestAssociationErrors(t *testing.T) {
+	create_and_populate_tagged_test_db()
+	publicId := crud_test_public_ids[0]
+
+	err := contactsService.Attach(publicId, "Tags", "t1", "unknown")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.Empty(t, tagNamesOf(t, publicId))
+
+	err = contactsService.Attach("unknown", "Tags", "t1")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	err = contactsService.Attach(publicId, "Addresses", "t1")
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+
+	_, err = contactsService.ListAssociated(publicId, "Tags", &DataFilter{Sort: "color"})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
+
+func TestAssociationOptions(t *testing.T) {
+	create_and_populate_tagged_test_db()
+	publicId := crud_test_public_ids[0]
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{AssociationOptions: map[string]AssociationOptions{
+			"tags": {PublicIdColumnName: "name", SortableColumns: []string{"name"}},
+		}})
+
+	assert.Nil(t, service.Attach(publicId, "Tags", "work", "family"))
+	assert.Equal(t, []string{"family", "work"}, tagNamesOf(t, publicId))
+	err := service.Attach(publicId, "Tags", "t3")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	page, err := ListAssociatedAs[TestTag, string](service, publicId, "Tags", &DataFilter{Limit: 1, Sort: "name:desc"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, page.TotalCount)
+	assert.Equal(t, "work", page.List[0].Name)
+	_, err = service.ListAssociated(publicId, "Tags", &DataFilter{Sort: "public_id"})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+
+	_, err = ListAssociatedAs[TestAddress, string](service, publicId, "Tags")
+	assert.NotNil(t, err)
+}
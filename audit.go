@@ -0,0 +1,259 @@
+package crud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuditEntry records a write of an entity: who did it, when, and how its fields changed.
+// Changes holds a FieldChange per changed field, by JSON name.
+type AuditEntry struct {
+	Id         int64           `json:"-"`
+	EntityType string          `gorm:"index:idx_audit_entity;size:255" json:"entityType"`
+	PublicId   string          `gorm:"index:idx_audit_entity;size:255" json:"publicId"`
+	Operation  Operation       `gorm:"size:16" json:"operation"`
+	Actor      string          `gorm:"size:255" json:"actor"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Changes    json.RawMessage `gorm:"type:text" json:"changes"`
+}
+
+// FieldChange is the value of a field before and after a write, null for created or deleted entities.
+type FieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// AutoMigrateAuditTable creates or migrates the audit table with the given name.
+func AutoMigrateAuditTable(db *gorm.DB, table string) error {
+	return db.Table(table).AutoMigrate(&AuditEntry{})
+}
+
+type actorContextKey struct{}
+
+// WithActor returns a copy of the given context telling the audit log who does the writes done with it.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFrom returns the actor set by WithActor on the given context, if any.
+func ActorFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+func (service *CrudServiceImpl[T, TPublicId]) isAudited() bool {
+	return len(service._options.AuditTable) > 0
+}
+
+// audited runs the given write along with its audit entries in a transaction if the service is audited.
+func (service *CrudServiceImpl[T, TPublicId]) audited(db *gorm.DB, write func(tx *gorm.DB) error) error {
+	if !service.isAudited() {
+		return write(db)
+	}
+	return db.Transaction(write)
+}
+
+// fieldValues returns the JSON values of the fields of the given entity by their JSON names, none if it is nil.
+func fieldValues(entity any) (map[string]json.RawMessage, error) {
+	var result map[string]json.RawMessage
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// diffFields returns the changes of the fields between the given versions of an entity, any of which may be nil.
+func diffFields(before any, after any) (map[string]FieldChange, error) {
+	from, err := fieldValues(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := fieldValues(after)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]FieldChange)
+	for key, value := range from {
+		if !bytes.Equal(value, to[key]) {
+			result[key] = FieldChange{From: value, To: to[key]}
+		}
+	}
+	for key, value := range to {
+		if _, ok := from[key]; !ok {
+			result[key] = FieldChange{To: value}
+		}
+	}
+	return result, nil
+}
+
+// recordAudit adds the audit entries of the given write, where before and after hold the same entities
+// before and after it. Before is empty for creations and after is empty for deletions.
+// Updates that do not change anything are not recorded.
+func (service *CrudServiceImpl[T, TPublicId]) recordAudit(tx *gorm.DB, op Operation, before []T, after []T) error {
+	if !service.isAudited() {
+		return nil
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return err
+	}
+	count := len(before)
+	if len(after) > count {
+		count = len(after)
+	}
+	entries := make([]AuditEntry, 0, count)
+	now := time.Now()
+	for i := 0; i < count; i++ {
+		var from, to *T
+		if i < len(before) {
+			from = &before[i]
+		}
+		if i < len(after) {
+			to = &after[i]
+		}
+		changes, err := diffFields(from, to)
+		if err != nil {
+			return err
+		}
+		if op == OpUpdate && len(changes) == 0 {
+			continue
+		}
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		entity := to
+		if entity == nil {
+			entity = from
+		}
+		entries = append(entries, AuditEntry{
+			EntityType: sch.Name,
+			PublicId:   fmt.Sprint(service.GetPublicId(*entity)),
+			Operation:  op,
+			Actor:      ActorFrom(service._ctx),
+			Timestamp:  now,
+			Changes:    data,
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return tx.Table(service._options.AuditTable).Create(&entries).Error
+}
+
+// recordUpdates records the updates of the given entities, read again at once to compare them with their previous state.
+func (service *CrudServiceImpl[T, TPublicId]) recordUpdates(tx *gorm.DB, before []T) error {
+	if !service.isAudited() || len(before) == 0 {
+		return nil
+	}
+	after, err := service.findByPrimaryKeys(tx, before)
+	if err != nil {
+		return err
+	}
+	if len(after) != len(before) {
+		return fmt.Errorf("%d of the updated entities are not found", len(before)-len(after))
+	}
+	return service.recordAudit(tx, OpUpdate, before, after)
+}
+
+// findByPrimaryKeys returns the entities with the primary keys of the given ones, in the same order.
+func (service *CrudServiceImpl[T, TPublicId]) findByPrimaryKeys(tx *gorm.DB, entities []T) ([]T, error) {
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%s has no primary key", sch.Name)
+	}
+	keys := make([]any, len(entities))
+	for i := range entities {
+		keys[i], _ = sch.PrioritizedPrimaryField.ValueOf(tx.Statement.Context, reflect.ValueOf(&entities[i]).Elem())
+	}
+	var found []T
+	column := clause.Column{Table: sch.Table, Name: sch.PrioritizedPrimaryField.DBName}
+	if err := tx.Model(new(T)).Where(clause.IN{Column: column, Values: keys}).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byKey := make(map[any]T, len(found))
+	for i := range found {
+		key, _ := sch.PrioritizedPrimaryField.ValueOf(tx.Statement.Context, reflect.ValueOf(&found[i]).Elem())
+		byKey[key] = found[i]
+	}
+	result := make([]T, 0, len(entities))
+	for _, key := range keys {
+		if entity, ok := byKey[key]; ok {
+			result = append(result, entity)
+		}
+	}
+	return result, nil
+}
+
+// auditSortableColumns are the columns audit entries can be sorted by.
+var auditSortableColumns = []string{"timestamp", "operation", "actor"}
+
+// History returns the page of the audit entries of the entity with the given public id, latest first
+// unless the filter sorts them by timestamp, operation or actor.
+func (service *CrudServiceImpl[T, TPublicId]) History(publicId TPublicId, filterParam ...*DataFilter) (*PagedList[AuditEntry], error) {
+	if !service.isAudited() {
+		return nil, NewCrudError(ErrInvalidFilter, "auditing is not enabled")
+	}
+	var filter *DataFilter
+	if len(filterParam) > 0 {
+		filter = filterParam[0]
+	}
+	filter = NormalizeFilter(filter, service._options.DefaultPageSize)
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+	auditStatement := &gorm.Statement{DB: db}
+	if err := auditStatement.Parse(&AuditEntry{}); err != nil {
+		return nil, err
+	}
+	orderBy, err := getOrderBy(auditStatement.Schema, auditSortableColumns, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(orderBy) == 0 {
+		orderBy = append(orderBy, clause.OrderByColumn{Column: clause.Column{Name: "timestamp"}, Desc: true})
+	}
+	// Entries of the same write share their timestamp, so the latest written come first among equals
+	orderBy = append(orderBy, clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: true})
+
+	query := db.Table(service._options.AuditTable).
+		Where("entity_type = ? AND public_id = ?", sch.Name, fmt.Sprint(publicId)).
+		Session(&gorm.Session{})
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, translateError(err)
+	}
+	var entries []AuditEntry
+	orderedQuery := query
+	for _, column := range orderBy {
+		orderedQuery = orderedQuery.Order(column)
+	}
+	err = orderedQuery.
+		Limit(filter.Limit).
+		Offset(filter.Page * filter.Limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return NewPagedList(entries, int(totalCount), filter), nil
+}
@@ -0,0 +1,134 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func create_audited_test_service() ExtendedCrudService[TestContact, string] {
+	create_and_populate_test_db(3)
+	if err := AutoMigrateAuditTable(crud_test_db, "test_audit_log"); err != nil {
+		panic(err)
+	}
+	return NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{AuditTable: "test_audit_log"},
+	)
+}
+
+func changesOf(t *testing.T, entry AuditEntry) map[string]FieldChange {
+	var changes map[string]FieldChange
+	assert.Nil(t, json.Unmarshal(entry.Changes, &changes))
+	return changes
+}
+
+func TestAuditLog(t *testing.T) {
+	service := create_audited_test_service()
+	ctx := WithActor(context.Background(), "alice")
+
+	created, err := service.WithContext(ctx).Create(&TestContact{FullName: "Audited", Code: 1})
+	assert.Nil(t, err)
+	created.Code = 2
+	created.Phone = "555"
+	_, err = service.WithContext(WithActor(ctx, "bob")).Update(created)
+	assert.Nil(t, err)
+	// Updates without changes are not recorded
+	_, err = service.WithContext(ctx).Update(created)
+	assert.Nil(t, err)
+	_, err = service.UpdateWhere(&TestContact{Email: "audited@mail.com"}, "full_name = ?", "Audited")
+	assert.Nil(t, err)
+	_, err = service.DeleteByPublicId(created.PublicId)
+	assert.Nil(t, err)
+
+	history, err := service.History(created.PublicId, Paged(0, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, history.TotalCount)
+	entries := history.List
+	assert.Equal(t, []Operation{OpDelete, OpUpdate, OpUpdate, OpCreate},
+		[]Operation{entries[0].Operation, entries[1].Operation, entries[2].Operation, entries[3].Operation})
+	assert.Equal(t, "TestContact", entries[3].EntityType)
+	assert.Equal(t, created.PublicId, entries[3].PublicId)
+	assert.Equal(t, "alice", entries[3].Actor)
+	assert.Equal(t, "bob", entries[2].Actor)
+	assert.Equal(t, "", entries[1].Actor)
+	assert.False(t, entries[3].Timestamp.IsZero())
+
+	created_changes := changesOf(t, entries[3])
+	assert.Equal(t, "null", string(created_changes["FullName"].From))
+	assert.Equal(t, `"Audited"`, string(created_changes["FullName"].To))
+
+	assert.Equal(t, map[string]FieldChange{
+		"Code":  {From: json.RawMessage("1"), To: json.RawMessage("2")},
+		"Phone": {From: json.RawMessage(`""`), To: json.RawMessage(`"555"`)},
+	}, changesOf(t, entries[2]))
+	assert.Equal(t, map[string]FieldChange{
+		"Email": {From: json.RawMessage(`""`), To: json.RawMessage(`"audited@mail.com"`)},
+	}, changesOf(t, entries[1]))
+
+	deleted_changes := changesOf(t, entries[0])
+	assert.Equal(t, `"audited@mail.com"`, string(deleted_changes["Email"].From))
+	assert.Equal(t, "null", string(deleted_changes["Email"].To))
+
+	history, err = service.History(created.PublicId, Paged(1, 3))
+	assert.Nil(t, err)
+	assert.Len(t, history.List, 1)
+	assert.Equal(t, OpCreate, history.List[0].Operation)
+}
+
+func TestAuditBulkWrites(t *testing.T) {
+	service := create_audited_test_service()
+
+	created, err := service.CreateAll([]TestContact{{FullName: "Bulk-1"}, {FullName: "Bulk-2"}})
+	assert.Nil(t, err)
+	_, err = service.DeleteWhere("full_name like ?", "Bulk-%")
+	assert.Nil(t, err)
+	for _, c := range created {
+		history, err := service.History(c.PublicId)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, history.TotalCount)
+	}
+
+	// A failed write is not recorded
+	_, err = service.Create(&TestContact{Id: 1, FullName: "Duplicate"})
+	assert.NotNil(t, err)
+	var count int64
+	crud_test_db.Table("test_audit_log").Count(&count)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestAuditDisabled(t *testing.T) {
+	create_and_populate_test_db(3)
+	_, err := contactsService.History(crud_test_public_ids[0])
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	assert.Equal(t, "", ActorFrom(context.Background()))
+}
+
+func TestAuditUpdateAllQueries(t *testing.T) {
+	service := create_audited_test_service()
+	contacts, _ := service.GetAll(&DataFilter{Limit: 10, Sort: "id"})
+	for i := range contacts.List {
+		contacts.List[i].Code = 10 + i
+	}
+
+	queries := 0
+	crud_test_db.Callback().Query().Before("gorm:query").Register("test:count_queries", func(db *gorm.DB) { queries++ })
+	defer crud_test_db.Callback().Query().Remove("test:count_queries")
+	_, err := service.UpdateAll(contacts.List)
+	assert.Nil(t, err)
+	// The entities are read once before and once after the updates, whatever their number
+	assert.Equal(t, 2, queries)
+
+	for i, c := range contacts.List {
+		history, err := service.History(c.PublicId)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, history.TotalCount)
+		assert.Equal(t, fmt.Sprintf(`{"Code":{"from":0,"to":%d}}`, 10+i), string(history.List[0].Changes))
+	}
+}
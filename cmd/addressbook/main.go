@@ -21,7 +21,11 @@ func Init() {
 	Db.AutoMigrate(
 		&Tag{},
 		&Contact{},
+		&Address{},
 	)
+	if err := crud.AutoMigrateAuditTable(Db, "audit_log"); err != nil {
+		log.Fatal("Failed to create the audit table: " + err.Error())
+	}
 }
 
 func CORSMiddleware() gin.HandlerFunc {
@@ -51,7 +55,12 @@ func main() {
 		func(e Contact) string { return e.PublicId },
 		func(t *Contact, a string) { t.PublicId = a },
 		&crud.CrudServiceOptions[Contact, string]{
-			LookupQuery: "full_name like ? or email like ?",
+			LookupQuery:     "full_name like ? or email like ?",
+			AllowedPreloads: []string{"Tags", "Addresses"},
+			AuditTable:      "audit_log",
+			LookupProfiles: map[string]crud.LookupProfile{
+				"name": {Fields: []crud.LookupField{{Column: "full_name", Weight: 2}, {Column: "email"}}},
+			},
 		},
 	)
 
@@ -64,10 +73,23 @@ func main() {
 		},
 	)
 
+	addressesRepo := crud.NewCrudService(
+		Db,
+		func(e Address) string { return e.PublicId },
+		func(t *Address, a string) { t.PublicId = a },
+		nil,
+	)
+
 	r.GET("", func(c *gin.Context) { c.String(200, "Running Ok") })
 
-	crud.AddCrudGinRestApi[Contact, string]("api/contacts", r, contactsRepo, &crud.CrudRestApiOptions[Contact, string]{})
+	crud.AddCrudGinRestApi[Contact, string]("api/contacts", r, contactsRepo, &crud.CrudRestApiOptions[Contact, string]{
+		Associations:       []string{"tags"},
+		AggregatableFields: []string{"address"},
+	})
 	crud.AddCrudGinRestApi[Tag, string]("api/tags", r, tagsRepo, &crud.CrudRestApiOptions[Tag, string]{})
+	if err := crud.AddCrudGinSubResource[Contact, string, Address, string]("api/contacts", "addresses", r, contactsRepo, addressesRepo, "contact_id", nil); err != nil {
+		panic(err)
+	}
 
 	r.Run(":5050")
 }
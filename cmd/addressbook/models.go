@@ -1,16 +1,29 @@
 package main
 
+import "gorm.io/gorm"
+
 type Contact struct {
-	Id       int `json:"-"`
-	FullName string `json:"full_name"`
-	PublicId string `gorm:"index:idx_contacts_public_id,unique" json:"public_id"`
-	Email    string `json:"email"`
-	Phone    string `json:"phone"`
-	Address  string `json:"address"`
+	Id        int            `json:"-"`
+	FullName  string         `json:"full_name"`
+	PublicId  string         `gorm:"index:idx_contacts_public_id,unique" json:"public_id"`
+	Email     string         `json:"email"`
+	Phone     string         `json:"phone"`
+	Address   string         `json:"address"`
+	Tags      []Tag          `gorm:"many2many:contact_tags" json:"tags"`
+	Addresses []Address      `json:"addresses"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
 }
 
 type Tag struct {
-	Id       int `json:"-"`
+	Id       int    `json:"-"`
 	PublicId string `gorm:"index:idx_tags_public_id,unique"`
 	Name     string
 }
+
+type Address struct {
+	Id        int    `json:"-"`
+	PublicId  string `gorm:"index:idx_addresses_public_id,unique" json:"public_id"`
+	ContactId int    `json:"-"`
+	Street    string `json:"street"`
+	City      string `json:"city"`
+}
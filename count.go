@@ -0,0 +1,165 @@
+package crud
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+// CountStrategy tells how paged queries compute the total count of their results.
+type CountStrategy string
+
+const (
+	// CountExact runs a separate COUNT(*) query, which is the default.
+	CountExact CountStrategy = "exact"
+	// CountSkip does not count at all: HasNext is found by fetching one more row than the page size
+	// and TotalCount and TotalPages are left zero.
+	CountSkip CountStrategy = "skip"
+	// CountEstimated reads the row count estimated by the database statistics for unfiltered queries
+	// on PostgreSQL, MySQL and SQL Server, and counts exactly otherwise.
+	CountEstimated CountStrategy = "estimated"
+	// CountWindow fetches the count along with the page in a single query using COUNT(*) OVER().
+	// Queries including associations and cursor-paged queries count exactly instead.
+	CountWindow CountStrategy = "window"
+)
+
+const windowCountColumn = "crud_total_count"
+
+// windowRows hides the trailing window count column of query results from gorm, reading it aside.
+type windowRows struct {
+	*sql.Rows
+	totalCount int64
+}
+
+func (rows *windowRows) Columns() ([]string, error) {
+	columns, err := rows.Rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	return columns[:len(columns)-1], nil
+}
+
+func (rows *windowRows) ColumnTypes() ([]*sql.ColumnType, error) {
+	columnTypes, err := rows.Rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	return columnTypes[:len(columnTypes)-1], nil
+}
+
+func (rows *windowRows) Scan(dest ...any) error {
+	return rows.Rows.Scan(append(dest, &rows.totalCount)...)
+}
+
+// getCountStrategy returns the count strategy of the filter, falling back to the one of the options.
+func (service *CrudServiceImpl[T, TPublicId]) getCountStrategy(filter *DataFilter) (CountStrategy, error) {
+	strategy := service._options.CountStrategy
+	if filter != nil && len(filter.Count) > 0 {
+		strategy = filter.Count
+	}
+	switch strategy {
+	case "":
+		return CountExact, nil
+	case CountExact, CountSkip, CountEstimated, CountWindow:
+		return strategy, nil
+	}
+	return "", NewCrudError(ErrInvalidFilter, fmt.Sprintf("unknown count strategy '%s'", strategy))
+}
+
+// countTotal counts the results of the given query, exactly or by estimation, and tells whether the count is exact.
+func (service *CrudServiceImpl[T, TPublicId]) countTotal(query *gorm.DB, strategy CountStrategy) (int64, bool, error) {
+	if strategy == CountEstimated {
+		if estimate, ok := service.estimateCount(query); ok {
+			return estimate, false, nil
+		}
+	}
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return 0, false, translateError(err)
+	}
+	return totalCount, true, nil
+}
+
+// estimateCount reads the row count of the table from the database statistics, if the query has no conditions.
+func (service *CrudServiceImpl[T, TPublicId]) estimateCount(query *gorm.DB) (int64, bool) {
+	if where, ok := query.Statement.Clauses["WHERE"]; ok {
+		if expr, ok := where.Expression.(clause.Where); !ok || len(expr.Exprs) > 0 {
+			return 0, false
+		}
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return 0, false
+	}
+	var sql string
+	switch query.Dialector.Name() {
+	case "postgres":
+		sql = "SELECT CAST(reltuples AS BIGINT) FROM pg_class WHERE oid = to_regclass(?)"
+	case "mysql":
+		sql = "SELECT table_rows FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	case "sqlserver":
+		sql = "SELECT SUM(row_count) FROM sys.dm_db_partition_stats WHERE object_id = OBJECT_ID(?) AND index_id < 2"
+	default:
+		return 0, false
+	}
+	var estimate *int64
+	if err := query.Session(&gorm.Session{NewDB: true}).Raw(sql, sch.Table).Scan(&estimate).Error; err != nil || estimate == nil || *estimate < 0 {
+		return 0, false
+	}
+	return *estimate, true
+}
+
+// findWithWindowCount fetches the given columns (all of them when empty) of the page of the given query
+// along with the total count of its results.
+func (service *CrudServiceImpl[T, TPublicId]) findWithWindowCount(query *gorm.DB, columns []string) ([]T, int64, error) {
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, 0, err
+	}
+	selection := []string{"?.*"}
+	vars := []any{clause.Table{Name: sch.Table}}
+	if len(columns) > 0 {
+		selection = make([]string, len(columns))
+		vars = make([]any, len(columns))
+		for i, column := range columns {
+			selection[i] = "?"
+			vars[i] = clause.Column{Name: column}
+		}
+	}
+	rows, err := query.
+		Select(strings.Join(selection, ", ")+", COUNT(*) OVER() AS "+windowCountColumn, vars...).
+		Rows()
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	defer rows.Close()
+
+	var result []T
+	tx := query.Session(&gorm.Session{NewDB: true})
+	if err := tx.Statement.Parse(&result); err != nil {
+		return nil, 0, err
+	}
+	tx.Statement.Dest = &result
+	tx.Statement.ReflectValue = reflect.ValueOf(&result).Elem()
+	wrappedRows := &windowRows{Rows: rows}
+	gorm.Scan(wrappedRows, tx, 0)
+	if tx.Error != nil {
+		return nil, 0, translateError(tx.Error)
+	}
+	// The rows are scanned out of the query callbacks, so the AfterFind hooks are run here
+	if tx.Statement.Schema.AfterFind && !query.Statement.SkipHooks {
+		for i := range result {
+			if hook, ok := any(&result[i]).(callbacks.AfterFindInterface); ok {
+				if err := hook.AfterFind(tx); err != nil {
+					return nil, 0, err
+				}
+			}
+		}
+	}
+	return result, wrappedRows.totalCount, nil
+}
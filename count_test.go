@@ -0,0 +1,105 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCountStrategies(t *testing.T) {
+	create_and_populate_coded_test_db()
+	findBy := map[string]any{"code": Gte(10)}
+
+	for _, strategy := range []CountStrategy{"", CountExact, CountEstimated, CountWindow} {
+		result, err := contactsService.GetAll(&DataFilter{Page: 1, Limit: 8, Sort: "code", Count: strategy, FindBy: findBy})
+		assert.Nil(t, err, strategy)
+		assert.Equal(t, 20, result.TotalCount, strategy)
+		assert.Equal(t, 3, result.TotalPages, strategy)
+		assert.True(t, result.CountExact, strategy)
+		assert.True(t, result.HasNext, strategy)
+		assert.Equal(t, []int{18, 19, 20, 21, 22, 23, 24, 25}, codesOf(result.List), strategy)
+	}
+}
+
+func TestSkipCount(t *testing.T) {
+	create_and_populate_coded_test_db()
+
+	result, err := contactsService.GetAll(&DataFilter{Page: 2, Limit: 10, Count: CountSkip})
+	assert.Nil(t, err)
+	assert.Len(t, result.List, 10)
+	assert.False(t, result.HasNext)
+	assert.True(t, result.HasPrevious)
+	assert.False(t, result.CountExact)
+	assert.Equal(t, 0, result.TotalCount)
+
+	result, err = contactsService.GetAll(&DataFilter{Page: 1, Limit: 10, Count: CountSkip})
+	assert.Nil(t, err)
+	assert.Len(t, result.List, 10)
+	assert.True(t, result.HasNext)
+
+	result, err = contactsService.GetAll(&DataFilter{Limit: 10, Cursor: true, Count: CountSkip})
+	assert.Nil(t, err)
+	assert.True(t, result.HasNext)
+	assert.False(t, result.CountExact)
+}
+
+func TestWindowCountPastTheEnd(t *testing.T) {
+	create_and_populate_test_db(5)
+
+	result, err := contactsService.GetAll(&DataFilter{Page: 3, Limit: 10, Count: CountWindow})
+	assert.Nil(t, err)
+	assert.Len(t, result.List, 0)
+	assert.Equal(t, 5, result.TotalCount)
+}
+
+func TestCountStrategyOption(t *testing.T) {
+	create_and_populate_test_db(5)
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{CountStrategy: CountSkip})
+
+	result, err := service.GetAll()
+	assert.Nil(t, err)
+	assert.False(t, result.CountExact)
+	first, err := service.FindOne()
+	assert.Nil(t, err)
+	assert.NotNil(t, first)
+
+	_, err = service.GetAll(&DataFilter{Count: "guess"})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
+
+type TestLabel struct {
+	Id       int
+	PublicId string `gorm:"index:idx_test_labels_public_id,unique"`
+	Name     string
+	Aliases  []string `gorm:"serializer:json"`
+	Display  string   `gorm:"-"`
+}
+
+func (l *TestLabel) AfterFind(tx *gorm.DB) error {
+	l.Display = "#" + l.Name
+	return nil
+}
+
+func TestWindowCountHooks(t *testing.T) {
+	create_and_populate_test_db(0)
+	crud_test_db.AutoMigrate(&TestLabel{})
+	service := NewCrudService(crud_test_db,
+		func(t TestLabel) string { return t.PublicId },
+		func(t *TestLabel, s string) { t.PublicId = s },
+		nil)
+	_, err := service.Create(&TestLabel{Name: "urgent", Aliases: []string{"asap", "now"}})
+	assert.Nil(t, err)
+
+	for _, strategy := range []CountStrategy{CountExact, CountWindow} {
+		result, err := service.GetAll(&DataFilter{Limit: 10, Count: strategy})
+		assert.Nil(t, err, strategy)
+		assert.Equal(t, 1, result.TotalCount, strategy)
+		assert.Equal(t, "#urgent", result.List[0].Display, strategy)
+		assert.Equal(t, []string{"asap", "now"}, result.List[0].Aliases, strategy)
+	}
+}
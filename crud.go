@@ -1,13 +1,21 @@
 package crud
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
+// CrudService is the core set of operations of a CRUD service. It is kept stable, so that implementing or mocking it
+// does not break: the other capabilities of CrudServiceImpl are defined by the smaller interfaces below.
 type CrudService[T any, TPublicId any] interface {
 	GetAll(filter ...*DataFilter) (*PagedList[T], error)
 	FindAll(criteria *T, filter ...*DataFilter) (*PagedList[T], error)
@@ -36,12 +44,140 @@ type CrudService[T any, TPublicId any] interface {
 	GetOptions() CrudServiceOptions[T, TPublicId]
 }
 
+// ContextBinder binds a service to a context or a transaction.
+type ContextBinder[T any, TPublicId any] interface {
+	// WithContext returns a copy of the service whose queries run with the given context,
+	// so they can be cancelled or bound to a deadline.
+	WithContext(ctx context.Context) ExtendedCrudService[T, TPublicId]
+	// WithTx returns a copy of the service whose queries run within the given transaction.
+	WithTx(tx *gorm.DB) ExtendedCrudService[T, TPublicId]
+}
+
+// SchemaProvider exposes the gorm schema of the entities of a service.
+type SchemaProvider interface {
+	GetSchema() (*schema.Schema, error)
+}
+
+// EntityValidator checks entities before they are written.
+type EntityValidator[T any] interface {
+	Validate(op Operation, entities []T) error
+}
+
+// FilterCounter counts the entities matching a filter.
+type FilterCounter interface {
+	CountBy(filter *DataFilter) (int, error)
+}
+
+// EntityIterator reads the entities matching a filter in batches.
+type EntityIterator[T any] interface {
+	Iterate(ctx context.Context, filter *DataFilter, batchSize int, fn func(batch []T) error) error
+}
+
+// PublicIdFinder finds an entity by its public id, loading the associations and selecting the fields of a filter.
+type PublicIdFinder[T any, TPublicId any] interface {
+	FindOneByPublicIdWith(publicId TPublicId, filter *DataFilter) (*T, error)
+}
+
+// ScopedUpdater updates entities by their public ids among the rows matching a query.
+type ScopedUpdater[T any] interface {
+	UpdateAllWhere(entities []T, query string, paramValues ...any) (int, error)
+}
+
+// ProfileLookup looks entities up by the named lookup profiles of the service options.
+type ProfileLookup[T any] interface {
+	LookupWithProfile(searchKey string, profile string, filter ...*DataFilter) (*PagedList[T], error)
+}
+
+// FullTextIndexer creates the full text index searched by Lookup.
+type FullTextIndexer interface {
+	CreateFullTextIndex() error
+}
+
+// EntityImporter creates entities from CSV or NDJSON data.
+type EntityImporter interface {
+	Import(reader io.Reader, options ImportOptions) (*ImportReport, error)
+}
+
+// AssociationManager manages the many to many associations of entities by public ids.
+type AssociationManager[TPublicId any] interface {
+	Attach(publicId TPublicId, association string, associatedPublicIds ...any) error
+	Detach(publicId TPublicId, association string, associatedPublicIds ...any) error
+	ReplaceAssociations(publicId TPublicId, association string, associatedPublicIds ...any) error
+	ListAssociated(publicId TPublicId, association string, filter ...*DataFilter) (*PagedList[any], error)
+}
+
+// Aggregator groups entities and computes metrics of each group.
+type Aggregator interface {
+	Aggregate(spec AggregateSpec, filter *DataFilter) ([]AggregateRow, error)
+}
+
+// AuditHistory lists the audit entries of entities.
+type AuditHistory[TPublicId any] interface {
+	History(publicId TPublicId, filter ...*DataFilter) (*PagedList[AuditEntry], error)
+}
+
+// SoftDeleter lists, restores and purges soft deleted entities.
+type SoftDeleter[T any, TPublicId any] interface {
+	Restore(publicIds []TPublicId) (int, error)
+	ListDeleted(filter ...*DataFilter) (*PagedList[T], error)
+	PurgeDeleted(olderThan time.Duration) (int, error)
+}
+
+// ExtendedCrudService is a CrudService with all the capabilities of CrudServiceImpl.
+// Unlike CrudService, it grows along with them.
+type ExtendedCrudService[T any, TPublicId any] interface {
+	CrudService[T, TPublicId]
+	ContextBinder[T, TPublicId]
+	SchemaProvider
+	EntityValidator[T]
+	FilterCounter
+	EntityIterator[T]
+	PublicIdFinder[T, TPublicId]
+	ScopedUpdater[T]
+	ProfileLookup[T]
+	FullTextIndexer
+	EntityImporter
+	AssociationManager[TPublicId]
+	Aggregator
+	AuditHistory[TPublicId]
+	SoftDeleter[T, TPublicId]
+}
+
 type CrudServiceOptions[T any, TPublicId any] struct {
 	DefaultPageSize         int
 	PublicIdColumnName      string
 	IdGenerator             IdGenerator[TPublicId]
 	DisableAutoIdGeneration bool
 	LookupQuery             string
+	// FullTextColumns, when set, make Lookup search an SQLite FTS5 index of these columns, ranking the results by bm25,
+	// instead of running the LookupQuery. The index is created by CreateFullTextIndex.
+	FullTextColumns []string
+	// LookupProfiles are the named lookup profiles of LookupWithProfile, e.g. LookupWithProfile("john smith", "name").
+	LookupProfiles map[string]LookupProfile
+	// QueryTimeout, when positive, bounds every database operation of the service.
+	QueryTimeout time.Duration
+	// Validators check entities before they are created or updated.
+	Validators []ValidateFunc[T]
+	// StructValidator checks the `validate` struct tags of entities, GetDefaultStructValidator() by default.
+	StructValidator         *validator.Validate
+	DisableStructValidation bool
+	// SortableColumns are the columns (or their JSON names) entities can be sorted by, all the columns by default.
+	SortableColumns []string
+	// AssociationOptions configure the many to many associations of the entities by association name.
+	AssociationOptions map[string]AssociationOptions
+	// AllowedPreloads are the associations (e.g. "Tags" or "Addresses.Country") that can be eagerly loaded
+	// by listing them, or their JSON names, in DataFilter.Include.
+	AllowedPreloads []string
+	// CountStrategy tells how paged queries count their results, CountExact by default.
+	CountStrategy CountStrategy
+	// AuditTable, when set, is the table every create, update and delete is recorded in, see AutoMigrateAuditTable.
+	AuditTable string
+	// DisableSoftDelete makes deletes permanent. Otherwise, entities with a gorm.DeletedAt field are soft deleted:
+	// they are hidden from queries until restored or purged.
+	DisableSoftDelete bool
+	// VersionColumnName, when set, is an integer version column, or a time one such as updated_at, checked by updates:
+	// updating an entity changed since it was read fails with a VersionConflictError.
+	VersionColumnName string
 }
 
 func GetDefaultCrudServiceOptions[T any, TPublicId any]() *CrudServiceOptions[T, TPublicId] {
@@ -59,6 +195,7 @@ type CrudServiceImpl[T any, TPublicId any] struct {
 	SetPublicId func(*T, TPublicId)
 	GetPublicId func(T) TPublicId
 	_options    *CrudServiceOptions[T, TPublicId]
+	_ctx        context.Context
 }
 
 func NewCrudService[T any, TPublicId any](db *gorm.DB, getPublicId func(T) TPublicId, setPublicId func(*T, TPublicId), options *CrudServiceOptions[T, TPublicId]) *CrudServiceImpl[T, TPublicId] {
@@ -84,64 +221,254 @@ func NewCrudService[T any, TPublicId any](db *gorm.DB, getPublicId func(T) TPubl
 		SetPublicId: setPublicId,
 		GetPublicId: getPublicId,
 		_options:    options,
+		_ctx:        context.Background(),
 	}
 }
 
-func (service *CrudServiceImpl[T, TPublicId]) FindAll(criteria *T, filterParam ...*DataFilter) (*PagedList[T], error) {
+func (service *CrudServiceImpl[T, TPublicId]) WithContext(ctx context.Context) ExtendedCrudService[T, TPublicId] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	clone := *service
+	clone._ctx = ctx
+	return &clone
+}
+
+func (service *CrudServiceImpl[T, TPublicId]) WithTx(tx *gorm.DB) ExtendedCrudService[T, TPublicId] {
+	clone := *service
+	clone._db = tx
+	if tx.Statement != nil && tx.Statement.Context != nil {
+		clone._ctx = tx.Statement.Context
+	}
+	return &clone
+}
+
+// getDb returns the connection bound to the service context, applying the configured query timeout.
+// The returned cancel function must always be called once the operation completes.
+func (service *CrudServiceImpl[T, TPublicId]) getDb() (*gorm.DB, context.CancelFunc) {
+	ctx := service._ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if service._options.QueryTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, service._options.QueryTimeout)
+		return service._db.WithContext(timeoutCtx), cancel
+	}
+	return service._db.WithContext(ctx), func() {}
+}
+
+// GetSchema returns the parsed gorm schema of the entity.
+func (service *CrudServiceImpl[T, TPublicId]) GetSchema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: service._db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// applyFilter adds the FindBy conditions of the filter to the given query.
+func (service *CrudServiceImpl[T, TPublicId]) applyFilter(query *gorm.DB, filter *DataFilter) (*gorm.DB, error) {
+	if filter == nil || len(filter.FindBy) == 0 {
+		return query, nil
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	conditions, err := buildFindByConditions(sch, filter.FindBy)
+	if err != nil {
+		return nil, err
+	}
+	if len(conditions) > 0 {
+		query = query.Where(clause.And(conditions...))
+	}
+	return query, nil
+}
+
+// normalizeFilter validates the sort specification of the filter and applies the defaults to it.
+func (service *CrudServiceImpl[T, TPublicId]) normalizeFilter(filter *DataFilter) (*DataFilter, error) {
+	if filter != nil && len(filter.Sort) > 0 {
+		if _, err := ParseSort(filter.Sort); err != nil {
+			return nil, err
+		}
+	}
+	return NormalizeFilter(filter, service._options.DefaultPageSize), nil
+}
+
+// getOrderBy resolves the sort columns of the filter, rejecting the ones that are not sortable.
+func (service *CrudServiceImpl[T, TPublicId]) getOrderBy(filter *DataFilter) ([]clause.OrderByColumn, error) {
+	if len(filter.SortBy) == 0 {
+		return make([]clause.OrderByColumn, 0), nil
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	return getOrderBy(sch, service._options.SortableColumns, filter)
+}
+
+// getOrderBy returns the order of the sort of the filter on the given schema, which can sort by the given columns only,
+// all of them when empty.
+func getOrderBy(sch *schema.Schema, sortableColumns []string, filter *DataFilter) ([]clause.OrderByColumn, error) {
+	result := make([]clause.OrderByColumn, 0, len(filter.SortBy))
+	for _, sortInfo := range filter.SortBy {
+		field := lookUpColumn(sch, sortInfo.Column)
+		if field == nil || !isSortable(sch, sortableColumns, field) {
+			return nil, NewCrudError(ErrInvalidFilter, fmt.Sprintf("cannot sort by '%s'", sortInfo.Column))
+		}
+		result = append(result, clause.OrderByColumn{Column: clause.Column{Name: field.DBName}, Desc: sortInfo.Desc})
+	}
+	return result, nil
+}
+
+func isSortable(sch *schema.Schema, sortableColumns []string, field *schema.Field) bool {
+	if len(sortableColumns) == 0 {
+		return true
+	}
+	for _, column := range sortableColumns {
+		if f := lookUpColumn(sch, column); f != nil && f.DBName == field.DBName {
+			return true
+		}
+	}
+	return false
+}
+
+// findPaged returns the page of entities matching the given query and filter.
+func (service *CrudServiceImpl[T, TPublicId]) findPaged(query *gorm.DB, filter *DataFilter) (*PagedList[T], error) {
 	var resultList []T
-	var filter *DataFilter
-	if len(filterParam) > 0 {
-		filter = filterParam[0]
+	filter, err := service.normalizeFilter(filter)
+	if err != nil {
+		return nil, err
 	}
-	filter = NormalizeFilter(filter, service._options.DefaultPageSize)
-	db_result := service._db.Model(new(T)).
-		Where(&criteria).
-		Order(GetOrderByQuery(filter)).
-		Limit(filter.Limit).
-		Offset(filter.Page * filter.Limit).
-		Find(&resultList)
-	totalCount, err := service.Count(criteria)
-	if db_result.Error != nil {
-		return nil, db_result.Error
+	orderBy, err := service.getOrderBy(filter)
+	if err != nil {
+		return nil, err
+	}
+	query, err = service.applyFilter(query, filter)
+	if err != nil {
+		return nil, err
 	}
+	strategy, err := service.getCountStrategy(filter)
 	if err != nil {
 		return nil, err
 	}
-	result := NewPagedList(resultList, totalCount, filter)
+	selectedFields, err := service.getSelectedFields(filter)
+	if err != nil {
+		return nil, err
+	}
+	preloads, err := service.getIncludes(filter)
+	if err != nil {
+		return nil, err
+	}
+	var selectedColumns []string
+	if selectedFields != nil {
+		keys, err := service.preloadKeys(preloads)
+		if err != nil {
+			return nil, err
+		}
+		selectedColumns = columnNames(selectedFields, keys...)
+	}
+	if strategy == CountWindow && len(preloads) > 0 {
+		strategy = CountExact
+	}
+	query = query.Session(&gorm.Session{})
+	if filter.IsCursorPaged() {
+		return service.findByKeyset(query, filter, orderBy, selectedColumns, preloads, strategy)
+	}
+	orderedQuery := query
+	for _, column := range orderBy {
+		orderedQuery = orderedQuery.Order(column)
+	}
+	limit := filter.Limit
+	if strategy == CountSkip {
+		limit++
+	}
+	orderedQuery = orderedQuery.
+		Limit(limit).
+		Offset(filter.Page * filter.Limit)
+
+	var totalCount int64
+	countExact := true
+	if strategy == CountWindow {
+		resultList, totalCount, err = service.findWithWindowCount(orderedQuery, selectedColumns)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if selectedColumns != nil {
+			orderedQuery = orderedQuery.Select(selectedColumns)
+		}
+		db_result := applyPreloads(orderedQuery, preloads).Find(&resultList)
+		if db_result.Error != nil {
+			return nil, translateError(db_result.Error)
+		}
+	}
+	hasMore := false
+	if strategy == CountSkip {
+		hasMore = len(resultList) > filter.Limit
+		if hasMore {
+			resultList = resultList[:filter.Limit]
+		}
+		countExact = false
+	} else if strategy != CountWindow || (len(resultList) == 0 && filter.Page > 0) {
+		// Pages past the end have no row to carry the window count, so those are counted separately
+		if totalCount, countExact, err = service.countTotal(query, strategy); err != nil {
+			return nil, err
+		}
+	}
+	result := NewPagedList(resultList, int(totalCount), filter)
+	result.CountExact = countExact
+	if strategy == CountSkip {
+		result.HasNext = hasMore
+	}
 	return result, nil
 }
 
+func (service *CrudServiceImpl[T, TPublicId]) FindAll(criteria *T, filterParam ...*DataFilter) (*PagedList[T], error) {
+	db, cancel := service.getDb()
+	defer cancel()
+	var filter *DataFilter
+	if len(filterParam) > 0 {
+		filter = filterParam[0]
+	}
+	query := db.Model(new(T))
+	if criteria != nil {
+		query = query.Where(criteria)
+	}
+	return service.findPaged(query, filter)
+}
+
 func (service *CrudServiceImpl[T, TPublicId]) FindAllWhere(query string, paramValuesAndFilter ...any) (*PagedList[T], error) {
-	var resultList []T
+	db, cancel := service.getDb()
+	defer cancel()
 	var filter *DataFilter
 	var paramValues []any
 	paramValuesCount := strings.Count(query, "?")
-	fmt.Printf("Params Count: %d", paramValuesCount)
 	if paramValuesCount < len(paramValuesAndFilter) {
 		paramValues = paramValuesAndFilter[:len(paramValuesAndFilter)-1]
-		filter = paramValuesAndFilter[len(paramValuesAndFilter)-1].(*DataFilter)
+		lastParam := paramValuesAndFilter[len(paramValuesAndFilter)-1]
+		if lastParam != nil {
+			var ok bool
+			if filter, ok = lastParam.(*DataFilter); !ok {
+				return nil, NewCrudError(ErrInvalidFilter, "the last parameter should be a *DataFilter")
+			}
+		}
 	} else {
 		paramValues = paramValuesAndFilter
 	}
-	filter = NormalizeFilter(filter, service._options.DefaultPageSize)
-	db_result := service._db.Model(new(T)).
-		Where(query, paramValues...).
-		Order(GetOrderByQuery(filter)).
-		Limit(filter.Limit).
-		Offset(filter.Page * filter.Limit).
-		Find(&resultList)
-	totalCount, err := service.CountWhere(query, paramValues...)
-	if db_result.Error != nil {
-		return nil, db_result.Error
-	}
-	if err != nil {
-		return nil, err
-	}
-	result := NewPagedList(resultList, totalCount, filter)
-	return result, nil
+	return service.findPaged(db.Model(new(T)).Where(query, paramValues...), filter)
 }
 
-func (service *CrudServiceImpl[T, TPublicId]) Lookup(searchKey string, filter ...*DataFilter) (*PagedList[T], error) {
+// Lookup returns the page of entities matching the given search key. It searches the full text index
+// if there are FullTextColumns and runs the LookupQuery otherwise.
+func (service *CrudServiceImpl[T, TPublicId]) Lookup(searchKey string, filterParam ...*DataFilter) (*PagedList[T], error) {
+	var filter *DataFilter
+	if len(filterParam) > 0 {
+		filter = filterParam[0]
+	}
+	if len(service._options.FullTextColumns) > 0 {
+		return service.fullTextLookup(searchKey, filter)
+	}
 	if len(service._options.LookupQuery) == 0 {
 		return nil, errors.New("lookup query should be provided when using NewCrudService options")
 	}
@@ -149,18 +476,20 @@ func (service *CrudServiceImpl[T, TPublicId]) Lookup(searchKey string, filter ..
 	for i := 0; i < strings.Count(service._options.LookupQuery, "?"); i++ {
 		params = append(params, "%"+searchKey+"%")
 	}
-	if len(filter) > 0 {
-		params = append(params, filter[0])
+	if filter != nil {
+		params = append(params, filter)
 	}
 	return service.FindAllWhere(service._options.LookupQuery, params...)
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) GetAll(filters ...*DataFilter) (*PagedList[T], error) {
+	db, cancel := service.getDb()
+	defer cancel()
 	var filter *DataFilter
 	if len(filters) > 0 {
 		filter = filters[0]
 	}
-	return service.FindAllWhere("1=1", filter)
+	return service.findPaged(db.Model(new(T)), filter)
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) FindOne(criteria ...*T) (*T, error) {
@@ -175,7 +504,7 @@ func (service *CrudServiceImpl[T, TPublicId]) FindOne(criteria ...*T) (*T, error
 	if err != nil {
 		return nil, err
 	}
-	if result.TotalCount == 0 {
+	if len(result.List) == 0 {
 		return nil, nil
 	}
 	return &result.List[0], nil
@@ -187,53 +516,114 @@ func (service *CrudServiceImpl[T, TPublicId]) FindOneWhere(query string, paramVa
 	if err != nil {
 		return nil, err
 	}
-	if result.TotalCount == 0 {
+	if len(result.List) == 0 {
 		return nil, nil
 	}
 	return &result.List[0], nil
 }
 
+// FindOneByPublicId returns the entity with the given public id, or ErrNotFound if there isn't any.
+// The Include and Fields of the given filter are applied to it.
 func (service *CrudServiceImpl[T, TPublicId]) FindOneByPublicId(publicId TPublicId) (*T, error) {
-	return service.FindOneWhere(service._options.PublicIdColumnName+" = ?", publicId)
+	return service.FindOneByPublicIdWith(publicId, nil)
+}
+
+// FindOneByPublicIdWith returns the entity with the given public id, or ErrNotFound if there isn't any.
+// The Include and Fields of the filter, if any, apply.
+func (service *CrudServiceImpl[T, TPublicId]) FindOneByPublicIdWith(publicId TPublicId, filter *DataFilter) (*T, error) {
+	oneFilter := &DataFilter{Limit: 1, Count: CountSkip}
+	if filter != nil {
+		oneFilter.Include = filter.Include
+		oneFilter.Fields = filter.Fields
+	}
+	result, err := service.FindAllWhere(service._options.PublicIdColumnName+" = ?", publicId, oneFilter)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.List) == 0 {
+		return nil, NewCrudError(ErrNotFound, fmt.Sprintf("no entity found with public id '%v'", publicId))
+	}
+	return &result.List[0], nil
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) CountWhere(query string, paramValues ...any) (int, error) {
+	db, cancel := service.getDb()
+	defer cancel()
 	var result int64
 	var model T
-	db_result := service._db.Model(&model).Where(query, paramValues...).Count(&result)
+	db_result := db.Model(&model).Where(query, paramValues...).Count(&result)
 	if db_result.Error != nil {
-		return 0, db_result.Error
+		return 0, translateError(db_result.Error)
 	}
 	return int(result), nil
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) Count(criteriaParam ...*T) (int, error) {
+	db, cancel := service.getDb()
+	defer cancel()
 	var result int64
 	var db_result *gorm.DB
 	if len(criteriaParam) > 0 {
-		db_result = service._db.Model(criteriaParam[0]).Where(criteriaParam[0]).Count(&result)
+		db_result = db.Model(criteriaParam[0]).Where(criteriaParam[0]).Count(&result)
 	} else {
-		db_result = service._db.Model(new(T)).Count(&result)
+		db_result = db.Model(new(T)).Count(&result)
 	}
 
 	if db_result.Error != nil {
-		return 0, db_result.Error
+		return 0, translateError(db_result.Error)
+	}
+	return int(result), nil
+}
+
+// CountBy counts the entities matching the FindBy conditions of the given filter.
+func (service *CrudServiceImpl[T, TPublicId]) CountBy(filter *DataFilter) (int, error) {
+	db, cancel := service.getDb()
+	defer cancel()
+	query, err := service.applyFilter(db.Model(new(T)), filter)
+	if err != nil {
+		return 0, err
+	}
+	var result int64
+	if err := query.Count(&result).Error; err != nil {
+		return 0, translateError(err)
 	}
 	return int(result), nil
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) CreateAll(entities []T) ([]T, error) {
+	if err := service.Validate(OpCreate, entities); err != nil {
+		return nil, err
+	}
+	return service.createAll(entities)
+}
+
+// createAll creates the given entities, which are validated already.
+func (service *CrudServiceImpl[T, TPublicId]) createAll(entities []T) ([]T, error) {
+	db, cancel := service.getDb()
+	defer cancel()
+	versionField, err := service.getVersionField()
+	if err != nil {
+		return nil, err
+	}
 	for i := range entities {
 		newId := service._options.IdGenerator.GetNewId()
 		service.SetPublicId(&entities[i], newId)
+		if err := initVersion(db, versionField, &entities[i]); err != nil {
+			return nil, err
+		}
 	}
-	db_result := service._db.Create(&entities)
-	return entities, db_result.Error
+	err = service.audited(db, func(tx *gorm.DB) error {
+		if err := tx.Create(&entities).Error; err != nil {
+			return err
+		}
+		return service.recordAudit(tx, OpCreate, nil, entities)
+	})
+	return entities, translateError(err)
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) Create(entity *T) (*T, error) {
 	if entity == nil {
-		return nil, errors.New("cannot create nil entity")
+		return nil, NewCrudError(ErrValidation, "cannot create nil entity")
 	}
 	result, err := service.CreateAll([]T{*entity})
 	if err != nil {
@@ -246,11 +636,32 @@ func (service *CrudServiceImpl[T, TPublicId]) Create(entity *T) (*T, error) {
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) Delete(criteria *T) (int, error) {
-	db_result := service._db.Where(criteria).Delete(new(T))
-	if db_result.Error != nil {
-		return 0, db_result.Error
+	db, cancel := service.getDb()
+	defer cancel()
+	return service.deleteWhere(db, func(tx *gorm.DB) *gorm.DB { return tx.Where(criteria) })
+}
+
+// deleteWhere deletes the entities matching the conditions added by where, recording them in the audit log.
+func (service *CrudServiceImpl[T, TPublicId]) deleteWhere(db *gorm.DB, where func(tx *gorm.DB) *gorm.DB) (int, error) {
+	rowsAffected := 0
+	err := service.audited(db, func(tx *gorm.DB) error {
+		var deleted []T
+		if service.isAudited() {
+			if err := where(service.deleteScope(tx).Model(new(T))).Find(&deleted).Error; err != nil {
+				return err
+			}
+		}
+		db_result := where(service.deleteScope(tx)).Delete(new(T))
+		if db_result.Error != nil {
+			return db_result.Error
+		}
+		rowsAffected = int(db_result.RowsAffected)
+		return service.recordAudit(tx, OpDelete, deleted, nil)
+	})
+	if err != nil {
+		return 0, translateError(err)
 	}
-	return int(db_result.RowsAffected), nil
+	return rowsAffected, nil
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) DeleteByPublicId(publicId TPublicId) (int, error) {
@@ -260,45 +671,153 @@ func (service *CrudServiceImpl[T, TPublicId]) DeleteByPublicId(publicId TPublicI
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) DeleteWhere(query string, paramValues ...any) (int, error) {
-	db_result := service._db.Where(query, paramValues...).Delete(new(T))
-	if db_result.Error != nil {
-		return 0, db_result.Error
-	}
-	return int(db_result.RowsAffected), nil
+	db, cancel := service.getDb()
+	defer cancel()
+	return service.deleteWhere(db, func(tx *gorm.DB) *gorm.DB { return tx.Where(query, paramValues...) })
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) DeleteAll(publicIds []TPublicId) (int, error) {
 	return service.DeleteWhere(service._options.PublicIdColumnName+" in ?", publicIds)
 }
 
+// UpdateAll updates the given entities by their public ids. If the service is versioned, entities are only
+// updated if they still have the given versions, which are then set to the new ones.
 func (service *CrudServiceImpl[T, TPublicId]) UpdateAll(entities []T) (int, error) {
+	return service.updateAll(entities, nil)
+}
+
+// UpdateAllWhere updates the given entities like UpdateAll, but only among the rows matching the given query.
+// It fails with ErrNotFound, updating none of them, if one of the entities is not among these rows.
+func (service *CrudServiceImpl[T, TPublicId]) UpdateAllWhere(entities []T, query string, paramValues ...any) (int, error) {
+	return service.updateAll(entities, func(tx *gorm.DB) *gorm.DB { return tx.Where(query, paramValues...) })
+}
+
+// updateAll updates the given entities by their public ids, restricted to the rows of the given scope if any.
+func (service *CrudServiceImpl[T, TPublicId]) updateAll(entities []T, scope func(tx *gorm.DB) *gorm.DB) (int, error) {
+	db, cancel := service.getDb()
+	defer cancel()
+	if err := service.Validate(OpUpdate, entities); err != nil {
+		return 0, err
+	}
+	versionField, err := service.getVersionField()
+	if err != nil {
+		return 0, err
+	}
+	// New versions are set on a copy, so that the entities are left as they are if the update fails
+	updated := make([]T, len(entities))
+	copy(updated, entities)
 	rowsAffected := 0
-	service._db.Transaction(func(tx *gorm.DB) error {
-		for _, e := range entities {
-			db_result := service._db.
-				Model(new(T)).
-				Where(service._options.PublicIdColumnName+" = ?", service.GetPublicId(e)).
-				Updates(e)
+	scoped := func(tx *gorm.DB) *gorm.DB {
+		if scope == nil {
+			return tx
+		}
+		return scope(tx)
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var before []T
+		if service.isAudited() && len(updated) > 0 {
+			publicIds := make([]TPublicId, len(updated))
+			for i := range updated {
+				publicIds[i] = service.GetPublicId(updated[i])
+			}
+			if err := scoped(tx.Model(new(T)).Where(service._options.PublicIdColumnName+" in ?", publicIds)).Find(&before).Error; err != nil {
+				return err
+			}
+		}
+		for i := range updated {
+			publicId := service.GetPublicId(updated[i])
+			query := scoped(tx.Model(new(T)).Where(service._options.PublicIdColumnName+" = ?", publicId))
+			if versionField != nil {
+				version, err := nextVersion(tx, versionField, &updated[i])
+				if err != nil {
+					return err
+				}
+				query = query.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: versionField.DBName}, Value: version})
+			}
+			db_result := query.Updates(updated[i])
 			if db_result.Error != nil {
 				return db_result.Error
 			}
 			rowsAffected += int(db_result.RowsAffected)
+			if scope != nil && db_result.RowsAffected == 0 {
+				// The row may be left unchanged, or have another version, rather than be out of the scope
+				var count int64
+				if err := scoped(tx.Model(new(T)).Where(service._options.PublicIdColumnName+" = ?", publicId)).Count(&count).Error; err != nil {
+					return err
+				}
+				if count == 0 {
+					return NewCrudError(ErrNotFound, fmt.Sprintf("no entity found with public id '%v'", publicId))
+				}
+			}
+			if versionField != nil {
+				if err := service.checkVersion(tx, versionField, &updated[i], db_result.RowsAffected); err != nil {
+					return err
+				}
+			}
 		}
-		return nil
+		return service.recordUpdates(tx, before)
 	})
+	if err != nil {
+		return 0, translateError(err)
+	}
+	copy(entities, updated)
 	return int(rowsAffected), nil
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) Update(entity *T) (int, error) {
-	return service.UpdateAll([]T{*entity})
+	if entity == nil {
+		return 0, NewCrudError(ErrValidation, "cannot update nil entity")
+	}
+	entities := []T{*entity}
+	rowsAffected, err := service.UpdateAll(entities)
+	if err == nil {
+		*entity = entities[0]
+	}
+	return rowsAffected, err
 }
 
+// UpdateWhere sets the fields set in the given entity on the rows matching the query, after validating them.
 func (service *CrudServiceImpl[T, TPublicId]) UpdateWhere(entity *T, query string, paramValues ...any) (int, error) {
-	db_result := service._db.Model(new(T)).Where(query, paramValues...).Updates(entity)
-	if db_result.Error != nil {
-		return 0, db_result.Error
+	if entity == nil {
+		return 0, NewCrudError(ErrValidation, "cannot update nil entity")
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+	if err := service.Validate(OpUpdate, []T{*entity}); err != nil {
+		return 0, err
+	}
+	versionField, err := service.getVersionField()
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected := 0
+	err = service.audited(db, func(tx *gorm.DB) error {
+		var before []T
+		if service.isAudited() {
+			if err := tx.Model(new(T)).Where(query, paramValues...).Find(&before).Error; err != nil {
+				return err
+			}
+		}
+		update := tx.Model(new(T)).Where(query, paramValues...)
+		if versionField != nil {
+			// Versions are changed first, as the update may change the columns of the query,
+			// and left out of the update, so that a version set in the entity does not overwrite them
+			if err := bumpVersions(tx.Model(new(T)).Where(query, paramValues...), versionField); err != nil {
+				return err
+			}
+			update = update.Omit(versionField.DBName)
+		}
+		db_result := update.Updates(entity)
+		if db_result.Error != nil {
+			return db_result.Error
+		}
+		rowsAffected = int(db_result.RowsAffected)
+		return service.recordUpdates(tx, before)
+	})
+	if err != nil {
+		return 0, translateError(err)
 	}
-	return int(db_result.RowsAffected), nil
+	return rowsAffected, nil
 }
 
 func (service *CrudServiceImpl[T, TPublicId]) GetOptions() CrudServiceOptions[T, TPublicId] {
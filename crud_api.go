@@ -1,42 +1,533 @@
 package crud
 
 import (
+	"encoding/json"
 	"errors"
-	"log"
+	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type CrudRestApiOptions[T any, TPublicId any] struct {
+	// ErrorRenderer writes the responses of failed requests, ProblemJsonErrorRenderer by default.
+	ErrorRenderer ErrorRenderer
+	// FilterableFields are the columns the list end-point can be filtered by, either with query parameters
+	// such as ?email=eq:a@b.com&code=gt:5 or with an RSQL expression in the filter parameter.
+	// Filtering is disabled when empty.
+	FilterableFields []string
+	// Associations are the many to many associations of the entity managed at baseUrl/:publicId/<association>,
+	// e.g. "tags" for api/contacts/:publicId/tags.
+	Associations []string
+	// AggregatableFields are the columns the baseUrl/_aggregate end-point can group by and compute metrics of,
+	// as in ?groupBy=city&metrics=count,avg:code. Aggregation is disabled when empty.
+	AggregatableFields []string
+	// StreamBatchSize is the number of entities read per query by the baseUrl/_stream end-point, 100 by default.
+	StreamBatchSize int
+	// ImportBatchSize is the number of rows inserted at once by the baseUrl/_import end-point, 100 by default.
+	ImportBatchSize int
+	// ExportColumns are the columns written by the baseUrl/_export end-point, unless selected by the fields parameter,
+	// all the fields having a JSON name by default.
+	ExportColumns []ExportColumn
+	// ActorFromRequest, when set, returns who makes a request, e.g. its authenticated user,
+	// which is set with WithActor on the context of the request for the audit log.
+	ActorFromRequest func(c *gin.Context) string
 }
 
-func AddCrudGinRestApi[T any, TPublicId any](baseUrl string, ginEngine *gin.Engine, crudService CrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId]) {
-	r := ginEngine
-	listEndPoint := func(c *gin.Context) {
+// ErrorRenderer writes the response of a request that failed with the given error.
+type ErrorRenderer func(c *gin.Context, err error)
+
+// ErrorResponse is the JSON body written by JsonErrorRenderer.
+type ErrorResponse struct {
+	Status  int          `json:"status"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	// CurrentVersion is the version of the entity on the server, for VersionConflictErrors.
+	CurrentVersion any `json:"currentVersion,omitempty"`
+}
+
+var errBadRequest = errors.New("bad request")
+
+// ErrorStatus returns the HTTP status code matching the kind of the given error.
+func ErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidFilter), errors.Is(err, ErrInvalidId), errors.Is(err, errBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrValidation):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrInvalidFilter):
+		return "invalid_filter"
+	case errors.Is(err, ErrInvalidId):
+		return "invalid_id"
+	case errors.Is(err, errBadRequest):
+		return "bad_request"
+	case errors.Is(err, ErrConflict):
+		return "conflict"
+	case errors.Is(err, ErrValidation):
+		return "validation_failed"
+	default:
+		return "internal_error"
+	}
+}
+
+// NewErrorResponse builds the response body for the given error.
+// Details of unexpected errors are not exposed to clients.
+func NewErrorResponse(err error) *ErrorResponse {
+	status := ErrorStatus(err)
+	response := &ErrorResponse{Status: status, Code: errorCode(err), Message: err.Error()}
+	if status == http.StatusInternalServerError {
+		response.Message = http.StatusText(status)
+	}
+	var crudErr *CrudError
+	if errors.As(err, &crudErr) {
+		response.Fields = crudErr.Fields
+	}
+	var conflictErr *VersionConflictError
+	if errors.As(err, &conflictErr) {
+		response.CurrentVersion = conflictErr.CurrentVersion
+	}
+	return response
+}
+
+// JsonErrorRenderer writes errors as ErrorResponse JSON documents.
+func JsonErrorRenderer(c *gin.Context, err error) {
+	response := NewErrorResponse(err)
+	c.JSON(response.Status, response)
+}
+
+// ProblemDetails is an RFC 7807 problem document.
+type ProblemDetails struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// CurrentVersion is the version of the entity on the server, for VersionConflictErrors.
+	CurrentVersion any `json:"currentVersion,omitempty"`
+}
+
+const ProblemJsonContentType = "application/problem+json"
+
+var problemTitles = map[string]string{
+	"not_found":         "Resource not found",
+	"invalid_filter":    "Invalid filter",
+	"invalid_id":        "Invalid id",
+	"bad_request":       "Bad request",
+	"conflict":          "Conflict",
+	"validation_failed": "Validation failed",
+}
+
+// NewProblemDetails builds the problem document for the given error of a request to instance.
+// Details of unexpected errors are not exposed to clients.
+func NewProblemDetails(err error, instance string) *ProblemDetails {
+	response := NewErrorResponse(err)
+	problem := &ProblemDetails{
+		Type:           "about:blank",
+		Title:          http.StatusText(response.Status),
+		Status:         response.Status,
+		Instance:       instance,
+		Errors:         response.Fields,
+		CurrentVersion: response.CurrentVersion,
+	}
+	if title, ok := problemTitles[response.Code]; ok {
+		problem.Type = "urn:problem-type:crud:" + response.Code
+		problem.Title = title
+		problem.Detail = response.Message
+	}
+	return problem
+}
+
+// ProblemJsonErrorRenderer writes errors as application/problem+json documents.
+func ProblemJsonErrorRenderer(c *gin.Context, err error) {
+	problem := NewProblemDetails(err, c.Request.URL.RequestURI())
+	c.Header("Content-Type", ProblemJsonContentType)
+	c.JSON(problem.Status, problem)
+}
+
+func abortWithError(c *gin.Context, renderError ErrorRenderer, err error) {
+	c.Error(err)
+	c.Abort()
+	renderError(c, err)
+}
+
+var filterOperators = map[FilterOperator]bool{
+	FilterEq: true, FilterNe: true, FilterGt: true, FilterGte: true, FilterLt: true, FilterLte: true,
+	FilterIn: true, FilterNotIn: true, FilterLike: true, FilterStartsWith: true, FilterBetween: true, FilterIsNull: true,
+}
+
+// ParseQueryFilters converts query parameters of the form field=operator:value into FindBy conditions.
+// Values without a known operator prefix are compared for equality. Repeating a field with the in, not-in
+// or between operators collects all of its values, otherwise the conditions of a repeated field are combined.
+// Only the given fields are considered.
+func ParseQueryFilters(query url.Values, fields []string) map[string]any {
+	result := make(map[string]any)
+	for _, field := range fields {
+		values, ok := query[field]
+		if !ok {
+			continue
+		}
+		conditions := make([]Condition, 0, len(values))
+		collected := make(map[FilterOperator]int)
+		for _, v := range values {
+			condition := Eq(v)
+			if i := strings.Index(v, ":"); i > 0 && filterOperators[FilterOperator(v[:i])] {
+				condition = Condition{Operator: FilterOperator(v[:i]), Value: v[i+1:]}
+			}
+			switch condition.Operator {
+			case FilterIn, FilterNotIn, FilterBetween:
+				if index, ok := collected[condition.Operator]; ok {
+					conditions[index].Value = append(conditions[index].Value.([]any), condition.Value)
+					continue
+				}
+				collected[condition.Operator] = len(conditions)
+				condition.Value = []any{condition.Value}
+			}
+			conditions = append(conditions, condition)
+		}
+		result[field] = conditions
+	}
+	return result
+}
+
+// getRawQueryValue returns the value of the given query parameter, allowing unescaped semicolons
+// in it as they are common in RSQL expressions but rejected by url.ParseQuery.
+func getRawQueryValue(rawQuery string, key string) (string, bool) {
+	for _, pair := range strings.Split(rawQuery, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+		if k, err := url.QueryUnescape(k); err != nil || k != key {
+			continue
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return "", false
+		}
+		return value, true
+	}
+	return "", false
+}
+
+func parsePublicIds[TPublicId any](src string) ([]TPublicId, error) {
+	publicIds := make([]TPublicId, 0)
+	for _, v := range strings.Split(src, ",") {
+		publicId, err := TryParse[TPublicId](v)
+		if err != nil {
+			return nil, err
+		}
+		publicIds = append(publicIds, publicId)
+	}
+	return publicIds, nil
+}
+
+// bindAggregateSpec reads the groupBy and metrics query parameters of an aggregate request,
+// rejecting the columns that are not aggregatable.
+func bindAggregateSpec(c *gin.Context, aggregatableFields []string) (*AggregateSpec, error) {
+	if len(aggregatableFields) == 0 {
+		return nil, NewCrudError(ErrInvalidFilter, "aggregation is not enabled")
+	}
+	allowed := make(map[string]bool)
+	for _, field := range aggregatableFields {
+		allowed[field] = true
+	}
+	spec := &AggregateSpec{GroupBy: splitFields(c.QueryArray("groupBy"))}
+	for _, column := range spec.GroupBy {
+		if !allowed[column] {
+			return nil, invalidFilter("cannot group by '%s'", column)
+		}
+	}
+	metrics, err := ParseMetrics(c.Query("metrics"))
+	if err != nil {
+		return nil, err
+	}
+	for _, metric := range metrics {
+		if len(metric.Column) > 0 && !allowed[metric.Column] {
+			return nil, invalidFilter("cannot aggregate '%s'", metric.Column)
+		}
+	}
+	spec.Metrics = metrics
+	return spec, nil
+}
+
+// bindPublicIds reads the list of public ids in the body of the request.
+func bindPublicIds(c *gin.Context) ([]any, error) {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.UseNumber()
+	var publicIds []any
+	if err := decoder.Decode(&publicIds); err != nil {
+		return nil, &CrudError{Kind: errBadRequest, Message: "a list of public ids is expected", Err: err}
+	}
+	for i, publicId := range publicIds {
+		switch v := publicId.(type) {
+		case json.Number:
+			publicIds[i] = v.String()
+		case string:
+		default:
+			return nil, &CrudError{Kind: errBadRequest, Message: "a list of public ids is expected"}
+		}
+	}
+	return publicIds, nil
+}
+
+// addAssociationEndPoints adds the end-points listing and changing the given many to many association of the entities.
+func addAssociationEndPoints[T any, TPublicId any](baseUrl string, association string, r gin.IRoutes, crudService ExtendedCrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId]) {
+	associationUrl := baseUrl + "/:publicId/" + association
+	r.GET(associationUrl, func(c *gin.Context) {
+		publicId, err := TryParse[TPublicId](c.Param("publicId"))
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
 		var filter DataFilter
-		if err := c.ShouldBind(&filter); err != nil {
+		if err := c.ShouldBindQuery(&filter); err != nil {
 			filter = *Paged(0, crudService.GetOptions().DefaultPageSize)
 		}
-		result, err := crudService.GetAll(&filter)
+		result, err := crudService.WithContext(c.Request.Context()).ListAssociated(publicId, association, &filter)
 		if err != nil {
-			c.AbortWithError(500, err)
+			abortWithError(c, options.ErrorRenderer, err)
 		} else {
 			c.JSON(200, result)
 		}
+	})
+
+	change := func(apply func(service ExtendedCrudService[T, TPublicId], publicId TPublicId, associatedPublicIds []any) error) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			publicId, err := TryParse[TPublicId](c.Param("publicId"))
+			if err != nil {
+				abortWithError(c, options.ErrorRenderer, err)
+				return
+			}
+			var associatedPublicIds []any
+			if associatedParam := c.Param("associatedPublicIds"); len(associatedParam) > 0 {
+				for _, id := range strings.Split(associatedParam, ",") {
+					associatedPublicIds = append(associatedPublicIds, id)
+				}
+			} else if associatedPublicIds, err = bindPublicIds(c); err != nil {
+				abortWithError(c, options.ErrorRenderer, err)
+				return
+			}
+			if err := apply(crudService.WithContext(c.Request.Context()), publicId, associatedPublicIds); err != nil {
+				abortWithError(c, options.ErrorRenderer, err)
+			} else {
+				c.Status(http.StatusNoContent)
+			}
+		}
+	}
+	r.POST(associationUrl, change(func(service ExtendedCrudService[T, TPublicId], publicId TPublicId, associatedPublicIds []any) error {
+		return service.Attach(publicId, association, associatedPublicIds...)
+	}))
+	r.PUT(associationUrl, change(func(service ExtendedCrudService[T, TPublicId], publicId TPublicId, associatedPublicIds []any) error {
+		return service.ReplaceAssociations(publicId, association, associatedPublicIds...)
+	}))
+	r.DELETE(associationUrl+"/:associatedPublicIds", change(func(service ExtendedCrudService[T, TPublicId], publicId TPublicId, associatedPublicIds []any) error {
+		return service.Detach(publicId, association, associatedPublicIds...)
+	}))
+}
+
+// getProjectedKeys returns the JSON names of the fields and associations the REST API should respond with
+// for the given filter.
+func getProjectedKeys[T any, TPublicId any](crudService CrudService[T, TPublicId], filter *DataFilter) ([]string, error) {
+	provider, ok := crudService.(SchemaProvider)
+	if !ok {
+		return nil, NewCrudError(ErrInvalidFilter, "selecting fields is not supported")
+	}
+	sch, err := provider.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	options := crudService.GetOptions()
+	return projectedKeys(sch, filter, options.PublicIdColumnName, options.AllowedPreloads)
+}
+
+// projectPagedList returns the given list with only the fields selected by the filter, along with the public ids
+// and included associations.
+func projectPagedList[T any, TPublicId any](crudService CrudService[T, TPublicId], list *PagedList[T], filter *DataFilter) (*PagedList[map[string]json.RawMessage], error) {
+	keys, err := getProjectedKeys(crudService, filter)
+	if err != nil {
+		return nil, err
+	}
+	projectedList, err := projectFields(keys, list.List)
+	if err != nil {
+		return nil, err
+	}
+	return &PagedList[map[string]json.RawMessage]{
+		List:        projectedList,
+		TotalCount:  list.TotalCount,
+		Page:        list.Page,
+		Limit:       list.Limit,
+		HasNext:     list.HasNext,
+		HasPrevious: list.HasPrevious,
+		TotalPages:  list.TotalPages,
+		Skip:        list.Skip,
+		NextCursor:  list.NextCursor,
+		PrevCursor:  list.PrevCursor,
+		CountExact:  list.CountExact,
+	}, nil
+}
+
+// bindListFilter reads the paging, sorting, projection and filtering of a list request from its query parameters.
+func bindListFilter[T any, TPublicId any](c *gin.Context, crudService CrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId]) (*DataFilter, error) {
+	var filter DataFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		filter = *Paged(0, crudService.GetOptions().DefaultPageSize)
+	}
+	filter.FindBy = make(map[string]any)
+	if len(options.FilterableFields) > 0 {
+		filter.FindBy = ParseQueryFilters(c.Request.URL.Query(), options.FilterableFields)
+	}
+	if expression, ok := getRawQueryValue(c.Request.URL.RawQuery, "filter"); ok {
+		if len(options.FilterableFields) == 0 {
+			return nil, NewCrudError(ErrInvalidFilter, "filtering is not enabled")
+		}
+		findBy, err := ParseRsql(expression, options.FilterableFields...)
+		if err != nil {
+			return nil, err
+		}
+		filter.FindBy[FindByAnd] = []map[string]any{findBy}
+	}
+	return &filter, nil
+}
+
+// apiRoutes returns the routes of the given engine to add end-points to, which set the actor of their requests
+// with actorFromRequest if it is given.
+func apiRoutes(ginEngine *gin.Engine, actorFromRequest func(c *gin.Context) string) gin.IRoutes {
+	if actorFromRequest == nil {
+		return ginEngine
+	}
+	return ginEngine.Group("", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithActor(c.Request.Context(), actorFromRequest(c)))
+	})
+}
+
+// bindContext returns the given service bound to the context of the request, if it can be.
+func bindContext[T any, TPublicId any](c *gin.Context, crudService CrudService[T, TPublicId]) CrudService[T, TPublicId] {
+	if binder, ok := crudService.(ContextBinder[T, TPublicId]); ok {
+		return binder.WithContext(c.Request.Context())
+	}
+	return crudService
+}
+
+// renderList responds with the page of entities matching the given filter.
+func renderList[T any, TPublicId any](c *gin.Context, crudService CrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId], filter *DataFilter) {
+	result, err := bindContext(c, crudService).GetAll(filter)
+	renderPage(c, crudService, options, filter, result, err)
+}
+
+// renderPage responds with the given page of entities, restricted to the fields selected by the filter if any,
+// or with the given error.
+func renderPage[T any, TPublicId any](c *gin.Context, crudService CrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId],
+	filter *DataFilter, result *PagedList[T], err error) {
+	if err != nil {
+		abortWithError(c, options.ErrorRenderer, err)
+	} else if len(filter.Fields) > 0 {
+		projected, err := projectPagedList(crudService, result, filter)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		c.JSON(200, projected)
+	} else {
+		c.JSON(200, result)
+	}
+}
+
+// renderOne responds with the given entity, restricted to the fields selected by the filter if any.
+func renderOne[T any, TPublicId any](c *gin.Context, crudService CrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId], entity *T, filter *DataFilter) {
+	if len(filter.Fields) == 0 {
+		c.JSON(200, entity)
+		return
+	}
+	keys, err := getProjectedKeys(crudService, filter)
+	if err != nil {
+		abortWithError(c, options.ErrorRenderer, err)
+		return
+	}
+	projected, err := projectFields(keys, []T{*entity})
+	if err != nil {
+		abortWithError(c, options.ErrorRenderer, err)
+		return
+	}
+	c.JSON(200, projected[0])
+}
+
+// AddCrudGinRestApi adds the REST end-points of the given service under baseUrl. The end-points of the capabilities
+// beyond CrudService, e.g. streaming, exports or aggregations, are only added for ExtendedCrudServices.
+func AddCrudGinRestApi[T any, TPublicId any](baseUrl string, ginEngine *gin.Engine, crudService CrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId]) {
+	if options == nil {
+		options = &CrudRestApiOptions[T, TPublicId]{}
+	}
+	if options.ErrorRenderer == nil {
+		options.ErrorRenderer = ProblemJsonErrorRenderer
+	}
+	r := apiRoutes(ginEngine, options.ActorFromRequest)
+	extended, isExtended := crudService.(ExtendedCrudService[T, TPublicId])
+	listEndPoint := func(c *gin.Context) {
+		filter, err := bindListFilter(c, crudService, options)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		if isExtended && acceptsNdjson(c) {
+			renderStream(c, extended, options, filter)
+		} else {
+			renderList(c, crudService, options, filter)
+		}
 	}
 
 	r.GET(baseUrl, listEndPoint)
 
+	r.GET(baseUrl+"/_lookup", func(c *gin.Context) {
+		filter, err := bindListFilter(c, crudService, options)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		service := bindContext(c, crudService)
+		var result *PagedList[T]
+		if profile := c.Query("profile"); len(profile) == 0 {
+			result, err = service.Lookup(c.Query("q"), filter)
+		} else if profileLookup, ok := service.(ProfileLookup[T]); ok {
+			result, err = profileLookup.LookupWithProfile(c.Query("q"), profile, filter)
+		} else {
+			err = NewCrudError(ErrInvalidFilter, "lookup profiles are not supported")
+		}
+		renderPage(c, crudService, options, filter, result, err)
+	})
+
 	getOneEndPoint := func(c *gin.Context) {
-		publicId := c.Param("publicId")
-		result, err := crudService.FindOneByPublicId(Parse[TPublicId](publicId))
+		publicId, err := TryParse[TPublicId](c.Param("publicId"))
 		if err != nil {
-			c.AbortWithError(500, err)
-		} else if result == nil {
-			c.AbortWithError(404, errors.New("not found"))
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		filter := &DataFilter{Include: c.QueryArray("include"), Fields: c.QueryArray("fields")}
+		service := bindContext(c, crudService)
+		var result *T
+		if finder, ok := service.(PublicIdFinder[T, TPublicId]); ok {
+			result, err = finder.FindOneByPublicIdWith(publicId, filter)
+		} else if len(filter.Include) > 0 {
+			err = NewCrudError(ErrInvalidFilter, "including associations is not supported")
 		} else {
-			c.JSON(200, result)
+			result, err = service.FindOneByPublicId(publicId)
+		}
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			renderOne(c, crudService, options, result, filter)
 		}
 	}
 
@@ -45,13 +536,12 @@ func AddCrudGinRestApi[T any, TPublicId any](baseUrl string, ginEngine *gin.Engi
 	r.POST(baseUrl, func(c *gin.Context) {
 		var entities []T
 		if err := c.ShouldBindJSON(&entities); err != nil {
-			c.AbortWithError(400, errors.New("invalid data to create"))
-			log.Printf("failed to bind create data: %v", err)
+			abortWithError(c, options.ErrorRenderer, &CrudError{Kind: errBadRequest, Message: "invalid data to create", Err: err})
 			return
 		}
-		result, err := crudService.CreateAll(entities)
+		result, err := bindContext(c, crudService).CreateAll(entities)
 		if err != nil {
-			c.AbortWithError(500, err)
+			abortWithError(c, options.ErrorRenderer, err)
 		} else {
 			c.JSON(200, result)
 		}
@@ -60,29 +550,122 @@ func AddCrudGinRestApi[T any, TPublicId any](baseUrl string, ginEngine *gin.Engi
 	r.PUT(baseUrl, func(c *gin.Context) {
 		var entities []T
 		if err := c.ShouldBindJSON(&entities); err != nil {
-			c.AbortWithError(400, errors.New("invalid data to create"))
+			abortWithError(c, options.ErrorRenderer, &CrudError{Kind: errBadRequest, Message: "invalid data to update", Err: err})
+			return
 		}
-		result, err := crudService.UpdateAll(entities)
+		result, err := bindContext(c, crudService).UpdateAll(entities)
 		if err != nil {
-			c.AbortWithError(500, err)
+			abortWithError(c, options.ErrorRenderer, err)
 		} else {
 			c.JSON(200, result)
 		}
 	})
 
-	r.DELETE(baseUrl+"/:publicIds", func(c *gin.Context) {
-		publicIdSrc := c.Param("publicIds")
-		publicIdStrings := strings.Split(publicIdSrc, ",")
-		publicIds := make([]TPublicId, 0)
-		for _, v := range publicIdStrings {
-			publicIds = append(publicIds, Parse[TPublicId](v))
+	r.DELETE(baseUrl+"/:publicId", func(c *gin.Context) {
+		publicIds, err := parsePublicIds[TPublicId](c.Param("publicId"))
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
 		}
-		result, err := crudService.DeleteAll(publicIds)
+		result, err := bindContext(c, crudService).DeleteAll(publicIds)
 		if err != nil {
-			c.AbortWithError(500, err)
+			abortWithError(c, options.ErrorRenderer, err)
 		} else {
 			c.JSON(200, result)
 		}
 	})
 
+	if isExtended {
+		addExtendedEndPoints(baseUrl, r, extended, options)
+	}
+}
+
+// addExtendedEndPoints adds the end-points of the capabilities beyond CrudService.
+func addExtendedEndPoints[T any, TPublicId any](baseUrl string, r gin.IRoutes, crudService ExtendedCrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId]) {
+	r.GET(baseUrl+"/_stream", func(c *gin.Context) {
+		filter, err := bindListFilter[T, TPublicId](c, crudService, options)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		renderStream(c, crudService, options, filter)
+	})
+
+	r.GET(baseUrl+"/_export", func(c *gin.Context) {
+		filter, err := bindListFilter[T, TPublicId](c, crudService, options)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		renderExport(c, crudService, options, filter)
+	})
+
+	r.GET(baseUrl+"/_aggregate", func(c *gin.Context) {
+		spec, err := bindAggregateSpec(c, options.AggregatableFields)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		filter, err := bindListFilter[T, TPublicId](c, crudService, options)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		result, err := crudService.WithContext(c.Request.Context()).Aggregate(*spec, filter)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			c.JSON(200, result)
+		}
+	})
+
+	r.GET(baseUrl+"/:publicId/_history", func(c *gin.Context) {
+		publicId, err := TryParse[TPublicId](c.Param("publicId"))
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		var filter DataFilter
+		if err := c.ShouldBindQuery(&filter); err != nil {
+			filter = *Paged(0, crudService.GetOptions().DefaultPageSize)
+		}
+		result, err := crudService.WithContext(c.Request.Context()).History(publicId, &filter)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			c.JSON(200, result)
+		}
+	})
+
+	r.POST(baseUrl+"/_import", func(c *gin.Context) {
+		renderImport(c, crudService, options)
+	})
+
+	r.POST(baseUrl+"/_restore", func(c *gin.Context) {
+		var publicIds []TPublicId
+		if err := c.ShouldBindJSON(&publicIds); err != nil {
+			abortWithError(c, options.ErrorRenderer, &CrudError{Kind: errBadRequest, Message: "a list of public ids is expected", Err: err})
+			return
+		}
+		result, err := crudService.WithContext(c.Request.Context()).Restore(publicIds)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			c.JSON(200, result)
+		}
+	})
+
+	r.GET(baseUrl+"/_trash", func(c *gin.Context) {
+		filter, err := bindListFilter[T, TPublicId](c, crudService, options)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		result, err := crudService.WithContext(c.Request.Context()).ListDeleted(filter)
+		renderPage[T, TPublicId](c, crudService, options, filter, result, err)
+	})
+
+	for _, association := range options.Associations {
+		addAssociationEndPoints(baseUrl, association, r, crudService, options)
+	}
 }
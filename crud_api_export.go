@@ -0,0 +1,77 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var exportContentTypes = map[ExportFormat]string{
+	ExportCsv:  "text/csv; charset=utf-8",
+	ExportXlsx: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportResponseWriter writes the headers of an export response along with its first bytes,
+// so that the errors occurring before can still be rendered.
+type exportResponseWriter struct {
+	c           *gin.Context
+	contentType string
+	fileName    string
+	started     bool
+}
+
+func (w *exportResponseWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.fileName))
+		w.c.Status(200)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// getExportColumns returns the columns selected by the fields parameter of an export request, in their order,
+// or the configured export columns if there is none. Configured columns keep their header and formatter when selected.
+func getExportColumns(fields []string, exportColumns []ExportColumn) []ExportColumn {
+	fields = splitFields(fields)
+	if len(fields) == 0 {
+		return exportColumns
+	}
+	result := make([]ExportColumn, len(fields))
+	for i, field := range fields {
+		result[i] = ExportColumn{Field: field}
+		for _, column := range exportColumns {
+			if strings.EqualFold(column.Field, field) {
+				result[i] = column
+				break
+			}
+		}
+	}
+	return result
+}
+
+// renderExport responds with the entities matching the given filter as a CSV or XLSX file.
+func renderExport[T any, TPublicId any](c *gin.Context, crudService ExtendedCrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId], filter *DataFilter) {
+	format := ExportFormat(strings.ToLower(c.DefaultQuery("format", string(ExportCsv))))
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		abortWithError(c, options.ErrorRenderer, NewCrudError(ErrInvalidFilter, "the export format should be csv or xlsx"))
+		return
+	}
+	sch, err := crudService.GetSchema()
+	if err != nil {
+		abortWithError(c, options.ErrorRenderer, err)
+		return
+	}
+	writer := &exportResponseWriter{c: c, contentType: contentType, fileName: sch.Table + "." + string(format)}
+	exportOptions := ExportOptions{Format: format, Columns: getExportColumns(filter.Fields, options.ExportColumns)}
+	if err := Export(c.Request.Context(), crudService, writer, filter, exportOptions); err != nil {
+		if !writer.started {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			_ = c.Error(err)
+			c.Abort()
+		}
+	}
+}
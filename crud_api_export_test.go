@@ -0,0 +1,51 @@
+package crud
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportApi(t *testing.T) {
+	create_and_populate_coded_test_db()
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{
+		FilterableFields: []string{"code"},
+		ExportColumns:    []ExportColumn{{Field: "FullName", Header: "Name"}, {Field: "Code"}},
+	})
+	export := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/"+test_api_contacts_path+"/_export"+query, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := export("?code=lt:3&sort=code:desc&limit=1")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="test_contacts.csv"`, w.Header().Get("Content-Disposition"))
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"Name", "Code"}, {"Cont-2", "2"}, {"Cont-1", "1"}, {"Cont-0", "0"}}, records)
+
+	// The fields parameter selects the columns, keeping the configured headers
+	w = export("?code=1&fields=code,email,FullName")
+	records, _ = csv.NewReader(w.Body).ReadAll()
+	assert.Equal(t, [][]string{{"Code", "Email", "Name"}, {"1", "", "Cont-1"}}, records)
+
+	w = export("?format=xlsx")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, exportContentTypes[ExportXlsx], w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "PK"))
+
+	w = export("?format=pdf")
+	assert.Equal(t, 400, w.Code)
+	w = export("?sort=nickname")
+	assert.Equal(t, 400, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "application/problem+json"))
+}
@@ -0,0 +1,72 @@
+package crud
+
+import (
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getImportFormat returns the format of the data of an import request, given by its format parameter,
+// the extension of the uploaded file or its content type.
+func getImportFormat(c *gin.Context, fileName string) (ImportFormat, error) {
+	format := c.Query("format")
+	if len(format) == 0 && len(fileName) > 0 {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	}
+	if len(format) == 0 {
+		mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+		switch mediaType {
+		case "text/csv":
+			format = string(ImportCsv)
+		case ndjsonContentType, "application/jsonl":
+			format = string(ImportNdjson)
+		case "application/json":
+			format = string(ImportJson)
+		}
+	}
+	switch strings.ToLower(format) {
+	case string(ImportCsv):
+		return ImportCsv, nil
+	case string(ImportNdjson), "jsonl":
+		return ImportNdjson, nil
+	case string(ImportJson):
+		return ImportJson, nil
+	}
+	return "", NewCrudError(errBadRequest, "the data to import should be CSV, NDJSON or JSON")
+}
+
+// renderImport imports the data of the request, either its body or the file uploaded as the file field
+// of a multipart form, and responds with the import report.
+func renderImport[T any, TPublicId any](c *gin.Context, crudService ExtendedCrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId]) {
+	var reader io.Reader = c.Request.Body
+	fileName := ""
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, &CrudError{Kind: errBadRequest, Message: "a file to import is expected", Err: err})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		defer file.Close()
+		reader = file
+		fileName = fileHeader.Filename
+	}
+	format, err := getImportFormat(c, fileName)
+	if err != nil {
+		abortWithError(c, options.ErrorRenderer, err)
+		return
+	}
+	report, err := crudService.WithContext(c.Request.Context()).Import(reader, ImportOptions{Format: format, BatchSize: options.ImportBatchSize})
+	if err != nil {
+		abortWithError(c, options.ErrorRenderer, err)
+	} else {
+		c.JSON(200, report)
+	}
+}
@@ -0,0 +1,59 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func import_req(urlPath string, contentType string, body *bytes.Buffer) (*httptest.ResponseRecorder, *ImportReport) {
+	r := setup_test_api()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/"+test_api_contacts_path+urlPath, body)
+	req.Header.Set("Content-Type", contentType)
+	r.ServeHTTP(w, req)
+	var report ImportReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	return w, &report
+}
+
+func TestImportApi(t *testing.T) {
+	w, report := import_req("/_import", "text/csv", bytes.NewBufferString("full_name,email\nImported-1,i1@mail.com\n,i2@mail.com\n"))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, []int{2}, report.Accepted)
+	assert.Len(t, report.Failed, 1)
+	assert.Equal(t, 3, report.Failed[0].Row)
+	assert.Equal(t, "FullName", report.Failed[0].Fields[0].Field)
+
+	w, report = import_req("/_import?format=ndjson", "text/plain", bytes.NewBufferString(`{"FullName": "Imported-1"}`+"\n"))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, []int{1}, report.Accepted)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, _ := form.CreateFormFile("file", "contacts.csv")
+	_, _ = file.Write([]byte("full_name\nImported-1\nImported-2\n"))
+	_ = form.Close()
+	w, report = import_req("/_import", form.FormDataContentType(), &body)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, []int{2, 3}, report.Accepted)
+	count, _ := contactsService.Count()
+	assert.Equal(t, seed_data_size+2, count)
+
+	w, report = import_req("/_import", "application/json", bytes.NewBufferString(`[{"FullName": "Imported-3"}, {"FullName": "Imported-4"}]`))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, []int{1, 1}, report.Accepted)
+
+	w, _ = import_req("/_import", "text/plain", bytes.NewBufferString("full_name\nImported-1\n"))
+	assert.Equal(t, 400, w.Code)
+
+	w, _ = import_req("/_import", "text/csv", bytes.NewBufferString("nickname\nImported-1\n"))
+	assert.Equal(t, 422, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), "nickname"))
+}
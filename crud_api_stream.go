@@ -0,0 +1,76 @@
+package crud
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// defaultStreamBatchSize is the number of entities read per query when streaming, unless configured otherwise.
+const defaultStreamBatchSize = 100
+
+// acceptsNdjson tells whether the client asks for newline delimited JSON.
+func acceptsNdjson(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ndjsonContentType)
+}
+
+// renderStream responds with all the entities matching the given filter as newline delimited JSON,
+// one object per line, writing every batch as soon as it is read. Paging parameters of the filter are ignored.
+// Errors are rendered as usual until the first entity is written; the response is cut short after that.
+func renderStream[T any, TPublicId any](c *gin.Context, crudService ExtendedCrudService[T, TPublicId], options *CrudRestApiOptions[T, TPublicId], filter *DataFilter) {
+	var keys []string
+	if len(filter.Fields) > 0 {
+		var err error
+		if keys, err = getProjectedKeys[T, TPublicId](crudService, filter); err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+	}
+	batchSize := options.StreamBatchSize
+	if batchSize < 1 {
+		batchSize = defaultStreamBatchSize
+	}
+	started := false
+	encoder := json.NewEncoder(c.Writer)
+	err := crudService.Iterate(c.Request.Context(), filter, batchSize, func(batch []T) error {
+		if !started {
+			c.Status(200)
+			c.Header("Content-Type", ndjsonContentType)
+			started = true
+		}
+		if keys != nil {
+			projected, err := projectFields(keys, batch)
+			if err != nil {
+				return err
+			}
+			for _, entity := range projected {
+				if err := encoder.Encode(entity); err != nil {
+					return err
+				}
+			}
+		} else {
+			for _, entity := range batch {
+				if err := encoder.Encode(entity); err != nil {
+					return err
+				}
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !started {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			_ = c.Error(err)
+			c.Abort()
+		}
+		return
+	}
+	if !started {
+		c.Data(200, ndjsonContentType, nil)
+	}
+}
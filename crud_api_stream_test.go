@@ -0,0 +1,74 @@
+package crud
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func stream_req(r *gin.Engine, urlPath string, accept string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/"+test_api_contacts_path+urlPath, nil)
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func streamedLines(t *testing.T, w *httptest.ResponseRecorder) []map[string]any {
+	result := make([]map[string]any, 0)
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var line map[string]any
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &line))
+		result = append(result, line)
+	}
+	return result
+}
+
+func TestStreamApi(t *testing.T) {
+	create_and_populate_coded_test_db()
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{
+		FilterableFields: []string{"code"},
+		StreamBatchSize:  4,
+	})
+
+	w := stream_req(r, "/_stream", "")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, ndjsonContentType, w.Header().Get("Content-Type"))
+	lines := streamedLines(t, w)
+	assert.Len(t, lines, 30)
+
+	// Paging parameters are ignored, the others apply
+	w = stream_req(r, "/_stream?code=gte:20&sort=code:desc&fields=code&limit=2&page=3", "")
+	assert.Equal(t, 200, w.Code)
+	lines = streamedLines(t, w)
+	assert.Len(t, lines, 10)
+	assert.Len(t, lines[0], 2)
+	assert.Equal(t, 29.0, lines[0]["Code"])
+	assert.NotEmpty(t, lines[0]["PublicId"])
+	assert.Equal(t, 20.0, lines[9]["Code"])
+
+	w = stream_req(r, "?code=lt:3&sort=code", "application/x-ndjson")
+	assert.Equal(t, 200, w.Code)
+	lines = streamedLines(t, w)
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "Cont-0", lines[0]["FullName"])
+
+	w = stream_req(r, "/_stream?code=gt:100", "")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, ndjsonContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, 0, w.Body.Len())
+
+	w = stream_req(r, "/_stream?sort=nickname", "")
+	assert.Equal(t, 400, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "application/problem+json"))
+}
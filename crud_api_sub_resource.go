@@ -0,0 +1,174 @@
+package crud
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getParentKey returns the primary key of the parent whose public id is in the publicId parameter of the request.
+func getParentKey[TParent any, TParentPublicId any](c *gin.Context, parentService ExtendedCrudService[TParent, TParentPublicId]) (any, error) {
+	publicId, err := TryParse[TParentPublicId](c.Param("publicId"))
+	if err != nil {
+		return nil, err
+	}
+	parent, err := parentService.WithContext(c.Request.Context()).FindOneByPublicId(publicId)
+	if err != nil {
+		return nil, err
+	}
+	sch, err := parentService.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%s has no primary key", sch.Name)
+	}
+	key, _ := sch.PrioritizedPrimaryField.ValueOf(c.Request.Context(), reflect.ValueOf(parent).Elem())
+	return key, nil
+}
+
+// AddCrudGinSubResource adds REST end-points for the children of a has-many relation under the end-point of their parent,
+// e.g. api/contacts/:publicId/addresses for the parent end-point api/contacts and the child path addresses.
+// Every operation is scoped to the parent found by its public id: children are listed, read, updated and deleted
+// only if their foreignKey column holds the primary key of the parent, and created children get it assigned.
+// It returns an error, adding no end-point, if the foreign key or the public id is not a column of the child.
+func AddCrudGinSubResource[TParent any, TParentPublicId any, TChild any, TChildPublicId any](
+	parentUrl string, childPath string, ginEngine *gin.Engine,
+	parentService ExtendedCrudService[TParent, TParentPublicId], childService ExtendedCrudService[TChild, TChildPublicId],
+	foreignKey string, options *CrudRestApiOptions[TChild, TChildPublicId]) error {
+	if options == nil {
+		options = &CrudRestApiOptions[TChild, TChildPublicId]{}
+	}
+	if options.ErrorRenderer == nil {
+		options.ErrorRenderer = ProblemJsonErrorRenderer
+	}
+	r := apiRoutes(ginEngine, options.ActorFromRequest)
+	childSchema, err := childService.GetSchema()
+	if err != nil {
+		return err
+	}
+	foreignKeyField := lookUpColumn(childSchema, foreignKey)
+	if foreignKeyField == nil {
+		return fmt.Errorf("foreign key '%s' is not a column of %s", foreignKey, childSchema.Name)
+	}
+	childPublicIdField := lookUpColumn(childSchema, childService.GetOptions().PublicIdColumnName)
+	if childPublicIdField == nil {
+		return fmt.Errorf("public id '%s' is not a column of %s", childService.GetOptions().PublicIdColumnName, childSchema.Name)
+	}
+	childPublicIdColumn := childPublicIdField.DBName
+	baseUrl := parentUrl + "/:publicId/" + childPath
+
+	r.GET(baseUrl, func(c *gin.Context) {
+		parentKey, err := getParentKey(c, parentService)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		filter, err := bindListFilter[TChild, TChildPublicId](c, childService, options)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		filter.FindBy[foreignKeyField.DBName] = Eq(parentKey)
+		renderList[TChild, TChildPublicId](c, childService, options, filter)
+	})
+
+	r.GET(baseUrl+"/:childPublicId", func(c *gin.Context) {
+		parentKey, err := getParentKey(c, parentService)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		publicId, err := TryParse[TChildPublicId](c.Param("childPublicId"))
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		filter := &DataFilter{
+			Limit:   1,
+			Count:   CountSkip,
+			Include: c.QueryArray("include"),
+			Fields:  c.QueryArray("fields"),
+			FindBy:  map[string]any{childPublicIdColumn: Eq(publicId), foreignKeyField.DBName: Eq(parentKey)},
+		}
+		result, err := childService.WithContext(c.Request.Context()).GetAll(filter)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else if len(result.List) == 0 {
+			abortWithError(c, options.ErrorRenderer, NewCrudError(ErrNotFound, fmt.Sprintf("no entity found with public id '%v'", publicId)))
+		} else {
+			renderOne[TChild, TChildPublicId](c, childService, options, &result.List[0], filter)
+		}
+	})
+
+	// bindChildren reads the children of the request body and assigns them to the parent.
+	bindChildren := func(c *gin.Context, message string) ([]TChild, any, bool) {
+		parentKey, err := getParentKey(c, parentService)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return nil, nil, false
+		}
+		var entities []TChild
+		if err := c.ShouldBindJSON(&entities); err != nil {
+			abortWithError(c, options.ErrorRenderer, &CrudError{Kind: errBadRequest, Message: message, Err: err})
+			return nil, nil, false
+		}
+		for i := range entities {
+			if err := foreignKeyField.Set(c.Request.Context(), reflect.ValueOf(&entities[i]).Elem(), parentKey); err != nil {
+				abortWithError(c, options.ErrorRenderer, &CrudError{Kind: errBadRequest, Message: message, Err: err})
+				return nil, nil, false
+			}
+		}
+		return entities, parentKey, true
+	}
+
+	r.POST(baseUrl, func(c *gin.Context) {
+		entities, _, ok := bindChildren(c, "invalid data to create")
+		if !ok {
+			return
+		}
+		result, err := childService.WithContext(c.Request.Context()).CreateAll(entities)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			c.JSON(200, result)
+		}
+	})
+
+	r.PUT(baseUrl, func(c *gin.Context) {
+		entities, parentKey, ok := bindChildren(c, "invalid data to update")
+		if !ok {
+			return
+		}
+		// Children of other parents are not found, so they cannot be moved to this one
+		result, err := childService.WithContext(c.Request.Context()).
+			UpdateAllWhere(entities, foreignKeyField.DBName+" = ?", parentKey)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			c.JSON(200, result)
+		}
+	})
+
+	r.DELETE(baseUrl+"/:childPublicId", func(c *gin.Context) {
+		parentKey, err := getParentKey(c, parentService)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		publicIds, err := parsePublicIds[TChildPublicId](c.Param("childPublicId"))
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+			return
+		}
+		result, err := childService.WithContext(c.Request.Context()).
+			DeleteWhere(childPublicIdColumn+" in ? and "+foreignKeyField.DBName+" = ?", publicIds, parentKey)
+		if err != nil {
+			abortWithError(c, options.ErrorRenderer, err)
+		} else {
+			c.JSON(200, result)
+		}
+	})
+	return nil
+}
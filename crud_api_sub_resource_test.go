@@ -0,0 +1,82 @@
+package crud
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setup_sub_resource_test_api() *gin.Engine {
+	create_and_populate_test_db(3)
+
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, nil)
+	if err := AddCrudGinSubResource(test_api_contacts_path, "addresses", r, contactsService, addressesService, "test_contact_id", nil); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func TestSubResourceApi(t *testing.T) {
+	r := setup_sub_resource_test_api()
+	first, second := crud_test_public_ids[0], crud_test_public_ids[1]
+
+	var created []TestAddress
+	code, err := post_req(first+"/addresses", r, []TestAddress{{City: "Addis Ababa"}, {City: "Nairobi", TestContactId: 2}}, &created)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Len(t, created, 2)
+	assert.Equal(t, 1, created[1].TestContactId)
+	post_req(second+"/addresses", r, []TestAddress{{City: "Kampala"}})
+
+	var list PagedList[TestAddress]
+	code, err = get_req(first+"/addresses", r, &list)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, list.TotalCount)
+
+	var one TestAddress
+	code, _ = get_req(first+"/addresses/"+created[0].PublicId, r, &one)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "Addis Ababa", one.City)
+	code, _ = get_req(second+"/addresses/"+created[0].PublicId, r)
+	assert.Equal(t, 404, code)
+	code, _ = get_req("unknown/addresses", r)
+	assert.Equal(t, 404, code)
+
+	created[0].City = "Adama"
+	code, _ = http_req("PUT", second+"/addresses", r, created[:1])
+	assert.Equal(t, 404, code)
+	code, _ = http_req("PUT", first+"/addresses", r, created[:1])
+	assert.Equal(t, 200, code)
+	address, _ := addressesService.FindOneByPublicId(created[0].PublicId)
+	assert.Equal(t, "Adama", address.City)
+	code, _ = http_req("PUT", first+"/addresses", r, []TestAddress{created[0], created[1], created[0]})
+	assert.Equal(t, 200, code)
+	var others PagedList[TestAddress]
+	get_req(second+"/addresses", r, &others)
+	others.List[0].City = "Gulu"
+	created[1].City = "Mombasa"
+	code, _ = http_req("PUT", first+"/addresses", r, []TestAddress{created[1], others.List[0]})
+	assert.Equal(t, 404, code)
+	address, _ = addressesService.FindOneByPublicId(created[1].PublicId)
+	assert.Equal(t, "Nairobi", address.City)
+
+	var deleted int
+	http_req("DELETE", second+"/addresses/"+created[0].PublicId, r, nil, &deleted)
+	assert.Equal(t, 0, deleted)
+	http_req("DELETE", first+"/addresses/"+created[0].PublicId+","+created[1].PublicId, r, nil, &deleted)
+	assert.Equal(t, 2, deleted)
+	count, _ := addressesService.Count()
+	assert.Equal(t, 1, count)
+}
+
+func TestSubResourceApiInvalidColumns(t *testing.T) {
+	create_and_populate_test_db(0)
+	r := gin.Default()
+
+	err := AddCrudGinSubResource(test_api_contacts_path, "addresses", r, contactsService, addressesService, "unknown_id", nil)
+	assert.ErrorContains(t, err, "unknown_id")
+	assert.Empty(t, r.Routes())
+}
@@ -22,7 +22,7 @@ func setup_test_api() *gin.Engine {
 	create_and_populate_test_db(seed_data_size)
 
 	r := gin.Default()
-	AddCrudGinRestApi(test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{})
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{})
 	//r.Run(":55000")
 
 	return r
@@ -218,3 +218,509 @@ func TestUpdateNonExistingApi(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Zero(t, rowsAffected)
 }
+
+func TestGetNotFoundApiBody(t *testing.T) {
+	r := setup_test_api()
+	var result ProblemDetails
+	code, err := get_req("non_existing_public_id", r, &result)
+
+	assert.Equal(t, 404, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, result.Status)
+	assert.Equal(t, "urn:problem-type:crud:not_found", result.Type)
+	assert.Equal(t, "Resource not found", result.Title)
+	assert.Equal(t, "/api/contacts/non_existing_public_id", result.Instance)
+	assert.NotEmpty(t, result.Detail)
+}
+
+func TestProblemJsonContentType(t *testing.T) {
+	r := setup_test_api()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/"+test_api_contacts_path+"/non_existing_public_id", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, ProblemJsonContentType, w.Header().Get("Content-Type"))
+}
+
+func TestJsonErrorRendererApi(t *testing.T) {
+	create_and_populate_test_db(seed_data_size)
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{
+		ErrorRenderer: JsonErrorRenderer,
+	})
+	var result ErrorResponse
+	code, err := get_req("non_existing_public_id", r, &result)
+
+	assert.Equal(t, 404, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, result.Status)
+	assert.Equal(t, "not_found", result.Code)
+}
+
+func TestCustomErrorRendererApi(t *testing.T) {
+	create_and_populate_test_db(seed_data_size)
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{
+		ErrorRenderer: func(c *gin.Context, err error) {
+			c.String(418, err.Error())
+		},
+	})
+	code, _ := get_req("non_existing_public_id", r)
+
+	assert.Equal(t, 418, code)
+}
+
+func TestCreateInvalidBodyApi(t *testing.T) {
+	r := setup_test_api()
+	var result ProblemDetails
+	code, err := post_req("", r, map[string]any{"FullName": "not a list"}, &result)
+
+	assert.Equal(t, 400, code)
+	assert.Nil(t, err)
+	assert.Equal(t, "urn:problem-type:crud:bad_request", result.Type)
+}
+
+func TestCreateConflictApi(t *testing.T) {
+	create_and_populate_test_db(seed_data_size)
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{IdGenerator: &constantIdGenerator{id: crud_test_public_ids[0]}},
+	)
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, service, nil)
+
+	var result ProblemDetails
+	code, err := post_req("", r, []TestContact{{FullName: "Mother Nature"}}, &result)
+
+	assert.Equal(t, 409, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 409, result.Status)
+	assert.Equal(t, "urn:problem-type:crud:conflict", result.Type)
+}
+
+func TestCreateInvalidApi(t *testing.T) {
+	r := setup_test_api()
+	entities := []TestContact{
+		{FullName: "Mother Nature", Email: "mona@gmail.com"},
+		{FullName: "Father Nature", Email: "fana"},
+	}
+	var result ProblemDetails
+	code, err := post_req("", r, entities, &result)
+
+	assert.Equal(t, 422, code)
+	assert.Nil(t, err)
+	assert.Equal(t, []FieldError{{Index: 1, Field: "Email", Message: "failed on the 'email' rule"}}, result.Errors)
+
+	var totalCount int64
+	crud_test_db.Model(&TestContact{}).Count(&totalCount)
+	assert.Equal(t, int64(seed_data_size), totalCount)
+}
+
+func setup_filterable_test_api() *gin.Engine {
+	create_and_populate_test_db(seed_data_size)
+	crud_test_db.Exec("UPDATE test_contacts SET code = id - 1")
+
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{
+		FilterableFields: []string{"full_name", "email", "code"},
+	})
+	return r
+}
+
+func TestFilteredListApi(t *testing.T) {
+	r := setup_filterable_test_api()
+	cases := map[string]int{
+		"?email=eq:c_3@gmail.com":             1,
+		"?email=c_3@gmail.com":                1,
+		"?code=gt:40":                         9,
+		"?code=gte:10&code=lt:20":             10,
+		"?code=in:1&code=in:2&code=in:3":      3,
+		"?code=not-in:1&code=not-in:2":        48,
+		"?code=between:10&code=between:14":    5,
+		"?full_name=like:Cont-1%25":           11,
+		"?full_name=starts-with:Cont-1":       11,
+		"?full_name=like:Cont-1%25&code=5":    0,
+		"?phone=c_3@gmail.com":                seed_data_size,
+		"?email=is-null:true":                 0,
+		"?email=c_3@gmail.com&limit=1&page=0": 1,
+	}
+	for query, expected := range cases {
+		var result PagedList[TestContact]
+		code, err := get_req(query, r, &result)
+
+		assert.Equal(t, 200, code, query)
+		assert.Nil(t, err, query)
+		assert.Equal(t, expected, result.TotalCount, query)
+	}
+}
+
+func TestFilteredListInvalidValueApi(t *testing.T) {
+	r := setup_filterable_test_api()
+	var result ProblemDetails
+	code, err := get_req("?code=gt:abc", r, &result)
+
+	assert.Equal(t, 400, code)
+	assert.Nil(t, err)
+	assert.Equal(t, "urn:problem-type:crud:invalid_filter", result.Type)
+}
+
+func TestParseQueryFilters(t *testing.T) {
+	query, _ := url.ParseQuery("code=in:1&code=in:2&code=gt:0&email=a:b&phone=1&page=2")
+	findBy := ParseQueryFilters(query, []string{"code", "email", "full_name"})
+
+	assert.Equal(t, map[string]any{
+		"code":  []Condition{In("1", "2"), Gt("0")},
+		"email": []Condition{Eq("a:b")},
+	}, findBy)
+}
+
+func TestRsqlFilteredListApi(t *testing.T) {
+	r := setup_filterable_test_api()
+	cases := map[string]int{
+		"?filter=full_name==Cont-1*":                                            11,
+		"?filter=full_name=like=Cont-1*;(code=gt=15,code<2)":                    5,
+		"?filter=code=in=(1,2,3)&code=lt:3":                                     2,
+		"?filter=" + url.QueryEscape("email=out=(c_1@gmail.com,c_2@gmail.com)"): 48,
+	}
+	for query, expected := range cases {
+		var result PagedList[TestContact]
+		code, err := get_req(query, r, &result)
+
+		assert.Equal(t, 200, code, query)
+		assert.Nil(t, err, query)
+		assert.Equal(t, expected, result.TotalCount, query)
+	}
+}
+
+func TestRsqlFilteredListInvalidApi(t *testing.T) {
+	r := setup_filterable_test_api()
+	for _, query := range []string{"?filter=full_name==", "?filter=phone==1", "?filter=(code==1"} {
+		var result ProblemDetails
+		code, err := get_req(query, r, &result)
+
+		assert.Equal(t, 400, code, query)
+		assert.Nil(t, err, query)
+		assert.Contains(t, result.Detail, "at position", query)
+	}
+}
+
+func TestSortedListApi(t *testing.T) {
+	r := setup_test_api()
+	var result PagedList[TestContact]
+	code, err := get_req("?sort=FullName:desc&limit=1", r, &result)
+
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, "Cont-9", result.List[0].FullName)
+
+	for _, sort := range []string{"(select+1)", "full_name:sideways"} {
+		code, _ = get_req("?sort="+sort, r)
+		assert.Equal(t, 400, code, sort)
+	}
+}
+
+func TestCursorListApi(t *testing.T) {
+	r := setup_test_api()
+	var first PagedList[TestContact]
+	code, err := get_req("?cursor=true&limit=6&sort=full_name", r, &first)
+
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Len(t, first.List, 6)
+	assert.NotEmpty(t, first.NextCursor)
+
+	var second PagedList[TestContact]
+	code, err = get_req("?limit=6&sort=full_name&after="+first.NextCursor, r, &second)
+
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, first.PrevCursor, "")
+	assert.True(t, first.List[5].FullName < second.List[0].FullName)
+
+	code, _ = get_req("?after=garbage", r)
+	assert.Equal(t, 400, code)
+}
+
+func TestSkipCountListApi(t *testing.T) {
+	r := setup_test_api()
+	var result PagedList[TestContact]
+	code, err := get_req("?count=skip&limit=2", r, &result)
+
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.True(t, result.HasNext)
+	assert.False(t, result.CountExact)
+
+	code, _ = get_req("?count=guess", r)
+	assert.Equal(t, 400, code)
+}
+
+func TestFieldsListApi(t *testing.T) {
+	r := setup_test_api()
+	var result PagedList[map[string]any]
+	code, err := get_req("?fields=FullName&limit=2", r, &result)
+
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Len(t, result.List, 2)
+	assert.Len(t, result.List[0], 2)
+	assert.Contains(t, result.List[0], "FullName")
+	assert.Contains(t, result.List[0], "PublicId")
+
+	code, _ = get_req("?fields=nickname", r)
+	assert.Equal(t, 400, code)
+}
+
+func TestIncludeApi(t *testing.T) {
+	r := setup_test_api()
+	contact, _ := contactsService.FindOneByPublicId(crud_test_public_ids[0])
+	contact.Tags = []TestTag{{PublicId: "t1", Name: "family"}}
+	crud_test_db.Save(contact)
+
+	var result PagedList[TestContact]
+	code, err := get_req("?include=Tags&limit=1", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Len(t, result.List[0].Tags, 1)
+
+	var one map[string]any
+	code, err = get_req(crud_test_public_ids[0]+"?include=tags&fields=full_name", r, &one)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Len(t, one, 3)
+	assert.Len(t, one["Tags"], 1)
+
+	code, _ = get_req(crud_test_public_ids[0]+"?include=friends", r)
+	assert.Equal(t, 400, code)
+}
+
+func TestAssociationsApi(t *testing.T) {
+	create_and_populate_tagged_test_db()
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{
+		Associations: []string{"tags"},
+	})
+	publicId := crud_test_public_ids[0]
+
+	code, _ := post_req(publicId+"/tags", r, []string{"t1", "t2"})
+	assert.Equal(t, 204, code)
+	code, _ = http_req("PUT", publicId+"/tags", r, []string{"t2", "t3"})
+	assert.Equal(t, 204, code)
+	code, _ = http_req("DELETE", publicId+"/tags/t3", r, nil)
+	assert.Equal(t, 204, code)
+
+	var result PagedList[TestTag]
+	code, err := get_req(publicId+"/tags", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.TotalCount)
+	assert.Equal(t, "work", result.List[0].Name)
+
+	code, _ = http_req("PUT", publicId+"/tags", r, []string{"t9"})
+	assert.Equal(t, 404, code)
+	code, _ = http_req("PUT", publicId+"/tags", r, map[string]string{"id": "t1"})
+	assert.Equal(t, 400, code)
+	code, _ = get_req(publicId+"/addresses", r)
+	assert.Equal(t, 404, code)
+}
+
+func TestAggregateApi(t *testing.T) {
+	create_and_populate_coded_test_db()
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, contactsService, &CrudRestApiOptions[TestContact, string]{
+		FilterableFields:   []string{"code"},
+		AggregatableFields: []string{"phone", "code"},
+	})
+	crud_test_db.Exec("UPDATE test_contacts SET phone = CASE WHEN code < 10 THEN 'Addis Ababa' ELSE 'Nairobi' END")
+
+	var result []AggregateRow
+	code, err := get_req("_aggregate?groupBy=phone&metrics=count,avg:code&code=lt:15&sort=count:desc", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "Addis Ababa", result[0].Keys["phone"])
+	assert.Equal(t, 10.0, result[0].Values["count"])
+	assert.Equal(t, 12.0, result[1].Values["avg_code"])
+
+	for _, query := range []string{"_aggregate?groupBy=email&metrics=count", "_aggregate?metrics=sum:id", "_aggregate?metrics=count,"} {
+		code, _ = get_req(query, r)
+		assert.Equal(t, 400, code, query)
+	}
+	code, _ = get_req(crud_test_public_ids[0], r)
+	assert.Equal(t, 200, code)
+
+	code, _ = get_req("_aggregate?metrics=count", setup_test_api())
+	assert.Equal(t, 400, code)
+}
+
+func TestLookupApi(t *testing.T) {
+	service := create_lookup_profile_test_service()
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, service, &CrudRestApiOptions[TestContact, string]{
+		FilterableFields: []string{"email"},
+	})
+
+	var result PagedList[TestContact]
+	code, err := get_req("_lookup?q=john+smith&profile=name", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Johnny Smithers", "John Smith", "Jane Smith"}, fullNamesOf(result.List))
+
+	code, err = get_req("_lookup?q=john&profile=name&email=like:%25mail.com&limit=2", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.TotalCount)
+	assert.Equal(t, []string{"John", "John Smith"}, fullNamesOf(result.List))
+
+	code, err = get_req("_lookup?q=smith", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.TotalCount)
+
+	code, _ = get_req("_lookup?q=john&profile=unknown", r)
+	assert.Equal(t, 400, code)
+}
+
+func TestHistoryApi(t *testing.T) {
+	service := create_audited_test_service()
+	r := gin.New()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, service, &CrudRestApiOptions[TestContact, string]{
+		ActorFromRequest: func(c *gin.Context) string { return "alice" },
+	})
+
+	var created []TestContact
+	code, err := post_req("", r, []TestContact{{FullName: "Audited", Code: 1}}, &created)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	created[0].Code = 2
+	code, _ = http_req("PUT", "", r, created)
+	assert.Equal(t, 200, code)
+
+	var result PagedList[AuditEntry]
+	code, err = get_req(created[0].PublicId+"/_history", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.TotalCount)
+	assert.Equal(t, OpUpdate, result.List[0].Operation)
+	assert.Equal(t, "alice", result.List[0].Actor)
+	assert.Equal(t, `{"Code":{"from":1,"to":2}}`, string(result.List[0].Changes))
+	assert.Equal(t, OpCreate, result.List[1].Operation)
+
+	code, err = get_req(created[0].PublicId+"/_history?page=1&limit=1", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Len(t, result.List, 1)
+	assert.Equal(t, OpCreate, result.List[0].Operation)
+
+	code, err = get_req(created[0].PublicId+"/_history?sort=operation", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, OpCreate, result.List[0].Operation)
+	assert.Equal(t, OpUpdate, result.List[1].Operation)
+	code, _ = get_req(created[0].PublicId+"/_history?sort=changes", r)
+	assert.Equal(t, 400, code)
+
+	// Entities without history
+	code, err = get_req(crud_test_public_ids[0]+"/_history", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.TotalCount)
+
+	// Services without an audit table
+	code, _ = get_req(crud_test_public_ids[0]+"/_history", setup_test_api())
+	assert.Equal(t, 400, code)
+}
+
+func TestSoftDeleteApi(t *testing.T) {
+	service := create_soft_delete_test_service(nil)
+	r := gin.Default()
+	AddCrudGinRestApi[TestNote, string]("api/notes", r, service, &CrudRestApiOptions[TestNote, string]{})
+	notes, _ := service.GetAll(&DataFilter{Sort: "text", Limit: 10})
+
+	request := func(method string, url string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		r.ServeHTTP(w, req)
+		return w
+	}
+	w := request("DELETE", "/api/notes/"+notes.List[0].PublicId+","+notes.List[1].PublicId, "")
+	assert.Equal(t, 200, w.Code)
+
+	var trash PagedList[TestNote]
+	w = request("GET", "/api/notes/_trash?sort=text", "")
+	assert.Equal(t, 200, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	assert.Equal(t, []string{"Note-0", "Note-1"}, textsOf(trash.List))
+
+	w = request("POST", "/api/notes/_restore", `["`+notes.List[1].PublicId+`"]`)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "1", w.Body.String())
+	w = request("GET", "/api/notes/_trash", "")
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	assert.Equal(t, []string{"Note-0"}, textsOf(trash.List))
+
+	w = request("POST", "/api/notes/_restore", `{"id": 1}`)
+	assert.Equal(t, 400, w.Code)
+
+	code, _ := get_req("_trash", setup_test_api())
+	assert.Equal(t, 400, code)
+}
+
+func TestVersionConflictApi(t *testing.T) {
+	service := create_version_test_service("version")
+	r := gin.Default()
+	AddCrudGinRestApi[TestDocument, string]("api/documents", r, service, nil)
+	doc, _ := service.Create(&TestDocument{Title: "Draft"})
+	doc.Title = "First"
+	service.Update(doc)
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal([]TestDocument{{PublicId: doc.PublicId, Title: "Stale", Version: 1}})
+	req, _ := http.NewRequest("PUT", "/api/documents", bytes.NewReader(body))
+	r.ServeHTTP(w, req)
+
+	var result ProblemDetails
+	assert.Equal(t, 409, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "urn:problem-type:crud:conflict", result.Type)
+	assert.Equal(t, float64(2), result.CurrentVersion)
+
+	w = httptest.NewRecorder()
+	body, _ = json.Marshal([]TestDocument{{PublicId: doc.PublicId, Title: "Second", Version: 2}})
+	req, _ = http.NewRequest("PUT", "/api/documents", bytes.NewReader(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	stored, _ := service.FindOneByPublicId(doc.PublicId)
+	assert.Equal(t, 3, stored.Version)
+}
+
+// coreCrudService hides the capabilities of the service it wraps beyond CrudService.
+type coreCrudService struct {
+	CrudService[TestContact, string]
+}
+
+func TestCoreServiceApi(t *testing.T) {
+	create_and_populate_test_db(seed_data_size)
+	r := gin.Default()
+	AddCrudGinRestApi[TestContact, string](test_api_contacts_path, r, coreCrudService{contactsService}, nil)
+
+	var result PagedList[TestContact]
+	code, err := get_req("?page=0&limit=3", r, &result)
+	assert.Equal(t, 200, code)
+	assert.Nil(t, err)
+	assert.Equal(t, seed_data_size, result.TotalCount)
+	assert.Len(t, result.List, 3)
+
+	code, _ = get_req(crud_test_public_ids[0], r)
+	assert.Equal(t, 200, code)
+	code, _ = get_req(crud_test_public_ids[0]+"?include=tags", r)
+	assert.Equal(t, 400, code)
+	code, _ = get_req("?fields=full_name", r)
+	assert.Equal(t, 400, code)
+	code, _ = get_req("_lookup?q=cont&profile=name", r)
+	assert.Equal(t, 400, code)
+	code, _ = http_req("POST", "_restore", r, []string{crud_test_public_ids[0]})
+	assert.Equal(t, 404, code)
+}
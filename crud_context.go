@@ -0,0 +1,210 @@
+package crud
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ContextCrudService is the context-first variant of CrudService.
+// Every operation takes the context it should run with as its first argument.
+type ContextCrudService[T any, TPublicId any] interface {
+	GetAll(ctx context.Context, filter ...*DataFilter) (*PagedList[T], error)
+	FindAll(ctx context.Context, criteria *T, filter ...*DataFilter) (*PagedList[T], error)
+	FindAllWhere(ctx context.Context, query string, paramValuesAndFilter ...any) (*PagedList[T], error)
+	Lookup(ctx context.Context, searchKey string, filter ...*DataFilter) (*PagedList[T], error)
+	LookupWithProfile(ctx context.Context, searchKey string, profile string, filter ...*DataFilter) (*PagedList[T], error)
+	CreateFullTextIndex(ctx context.Context) error
+	Iterate(ctx context.Context, filter *DataFilter, batchSize int, fn func(batch []T) error) error
+
+	FindOne(ctx context.Context, criteria ...*T) (*T, error)
+	FindOneByPublicId(ctx context.Context, publicId TPublicId) (*T, error)
+	FindOneByPublicIdWith(ctx context.Context, publicId TPublicId, filter *DataFilter) (*T, error)
+	FindOneWhere(ctx context.Context, query string, paramValues ...any) (*T, error)
+
+	Count(ctx context.Context, criteria ...*T) (int, error)
+	CountWhere(ctx context.Context, query string, paramValues ...any) (int, error)
+	CountBy(ctx context.Context, filter *DataFilter) (int, error)
+
+	CreateAll(ctx context.Context, entities []T) ([]T, error)
+	Create(ctx context.Context, entity *T) (*T, error)
+	Import(ctx context.Context, reader io.Reader, options ImportOptions) (*ImportReport, error)
+
+	Delete(ctx context.Context, criteria *T) (int, error)
+	DeleteByPublicId(ctx context.Context, publicId TPublicId) (int, error)
+	DeleteAll(ctx context.Context, publicIds []TPublicId) (int, error)
+	DeleteWhere(ctx context.Context, query string, paramValues ...any) (int, error)
+	Restore(ctx context.Context, publicIds []TPublicId) (int, error)
+	ListDeleted(ctx context.Context, filter ...*DataFilter) (*PagedList[T], error)
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int, error)
+
+	Update(ctx context.Context, entity *T) (int, error)
+	UpdateAll(ctx context.Context, entities []T) (int, error)
+	UpdateAllWhere(ctx context.Context, entities []T, query string, paramValues ...any) (int, error)
+	UpdateWhere(ctx context.Context, entity *T, query string, paramValues ...any) (int, error)
+
+	Validate(ctx context.Context, op Operation, entities []T) error
+
+	Attach(ctx context.Context, publicId TPublicId, association string, associatedPublicIds ...any) error
+	Detach(ctx context.Context, publicId TPublicId, association string, associatedPublicIds ...any) error
+	ReplaceAssociations(ctx context.Context, publicId TPublicId, association string, associatedPublicIds ...any) error
+	ListAssociated(ctx context.Context, publicId TPublicId, association string, filter ...*DataFilter) (*PagedList[any], error)
+
+	Aggregate(ctx context.Context, spec AggregateSpec, filter *DataFilter) ([]AggregateRow, error)
+	History(ctx context.Context, publicId TPublicId, filter ...*DataFilter) (*PagedList[AuditEntry], error)
+
+	GetOptions() CrudServiceOptions[T, TPublicId]
+}
+
+type ContextCrudServiceImpl[T any, TPublicId any] struct {
+	_service ExtendedCrudService[T, TPublicId]
+}
+
+// NewContextCrudService wraps the given service into its context-first variant.
+func NewContextCrudService[T any, TPublicId any](service ExtendedCrudService[T, TPublicId]) *ContextCrudServiceImpl[T, TPublicId] {
+	return &ContextCrudServiceImpl[T, TPublicId]{_service: service}
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) GetAll(ctx context.Context, filter ...*DataFilter) (*PagedList[T], error) {
+	return service._service.WithContext(ctx).GetAll(filter...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) FindAll(ctx context.Context, criteria *T, filter ...*DataFilter) (*PagedList[T], error) {
+	return service._service.WithContext(ctx).FindAll(criteria, filter...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) FindAllWhere(ctx context.Context, query string, paramValuesAndFilter ...any) (*PagedList[T], error) {
+	return service._service.WithContext(ctx).FindAllWhere(query, paramValuesAndFilter...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Lookup(ctx context.Context, searchKey string, filter ...*DataFilter) (*PagedList[T], error) {
+	return service._service.WithContext(ctx).Lookup(searchKey, filter...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) LookupWithProfile(ctx context.Context, searchKey string, profile string, filter ...*DataFilter) (*PagedList[T], error) {
+	return service._service.WithContext(ctx).LookupWithProfile(searchKey, profile, filter...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) CreateFullTextIndex(ctx context.Context) error {
+	return service._service.WithContext(ctx).CreateFullTextIndex()
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Iterate(ctx context.Context, filter *DataFilter, batchSize int, fn func(batch []T) error) error {
+	return service._service.Iterate(ctx, filter, batchSize, fn)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) FindOne(ctx context.Context, criteria ...*T) (*T, error) {
+	return service._service.WithContext(ctx).FindOne(criteria...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) FindOneByPublicId(ctx context.Context, publicId TPublicId) (*T, error) {
+	return service._service.WithContext(ctx).FindOneByPublicId(publicId)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) FindOneByPublicIdWith(ctx context.Context, publicId TPublicId, filter *DataFilter) (*T, error) {
+	return service._service.WithContext(ctx).FindOneByPublicIdWith(publicId, filter)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) FindOneWhere(ctx context.Context, query string, paramValues ...any) (*T, error) {
+	return service._service.WithContext(ctx).FindOneWhere(query, paramValues...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Count(ctx context.Context, criteria ...*T) (int, error) {
+	return service._service.WithContext(ctx).Count(criteria...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) CountWhere(ctx context.Context, query string, paramValues ...any) (int, error) {
+	return service._service.WithContext(ctx).CountWhere(query, paramValues...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) CountBy(ctx context.Context, filter *DataFilter) (int, error) {
+	return service._service.WithContext(ctx).CountBy(filter)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) CreateAll(ctx context.Context, entities []T) ([]T, error) {
+	return service._service.WithContext(ctx).CreateAll(entities)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Create(ctx context.Context, entity *T) (*T, error) {
+	return service._service.WithContext(ctx).Create(entity)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Import(ctx context.Context, reader io.Reader, options ImportOptions) (*ImportReport, error) {
+	return service._service.WithContext(ctx).Import(reader, options)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Delete(ctx context.Context, criteria *T) (int, error) {
+	return service._service.WithContext(ctx).Delete(criteria)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) DeleteByPublicId(ctx context.Context, publicId TPublicId) (int, error) {
+	return service._service.WithContext(ctx).DeleteByPublicId(publicId)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) DeleteAll(ctx context.Context, publicIds []TPublicId) (int, error) {
+	return service._service.WithContext(ctx).DeleteAll(publicIds)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) DeleteWhere(ctx context.Context, query string, paramValues ...any) (int, error) {
+	return service._service.WithContext(ctx).DeleteWhere(query, paramValues...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Restore(ctx context.Context, publicIds []TPublicId) (int, error) {
+	return service._service.WithContext(ctx).Restore(publicIds)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) ListDeleted(ctx context.Context, filter ...*DataFilter) (*PagedList[T], error) {
+	return service._service.WithContext(ctx).ListDeleted(filter...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int, error) {
+	return service._service.WithContext(ctx).PurgeDeleted(olderThan)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Update(ctx context.Context, entity *T) (int, error) {
+	return service._service.WithContext(ctx).Update(entity)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) UpdateAll(ctx context.Context, entities []T) (int, error) {
+	return service._service.WithContext(ctx).UpdateAll(entities)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) UpdateAllWhere(ctx context.Context, entities []T, query string, paramValues ...any) (int, error) {
+	return service._service.WithContext(ctx).UpdateAllWhere(entities, query, paramValues...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) UpdateWhere(ctx context.Context, entity *T, query string, paramValues ...any) (int, error) {
+	return service._service.WithContext(ctx).UpdateWhere(entity, query, paramValues...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Validate(ctx context.Context, op Operation, entities []T) error {
+	return service._service.WithContext(ctx).Validate(op, entities)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Attach(ctx context.Context, publicId TPublicId, association string, associatedPublicIds ...any) error {
+	return service._service.WithContext(ctx).Attach(publicId, association, associatedPublicIds...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Detach(ctx context.Context, publicId TPublicId, association string, associatedPublicIds ...any) error {
+	return service._service.WithContext(ctx).Detach(publicId, association, associatedPublicIds...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) ReplaceAssociations(ctx context.Context, publicId TPublicId, association string, associatedPublicIds ...any) error {
+	return service._service.WithContext(ctx).ReplaceAssociations(publicId, association, associatedPublicIds...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) ListAssociated(ctx context.Context, publicId TPublicId, association string, filter ...*DataFilter) (*PagedList[any], error) {
+	return service._service.WithContext(ctx).ListAssociated(publicId, association, filter...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) Aggregate(ctx context.Context, spec AggregateSpec, filter *DataFilter) ([]AggregateRow, error) {
+	return service._service.WithContext(ctx).Aggregate(spec, filter)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) History(ctx context.Context, publicId TPublicId, filter ...*DataFilter) (*PagedList[AuditEntry], error) {
+	return service._service.WithContext(ctx).History(publicId, filter...)
+}
+
+func (service *ContextCrudServiceImpl[T, TPublicId]) GetOptions() CrudServiceOptions[T, TPublicId] {
+	return service._service.GetOptions()
+}
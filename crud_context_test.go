@@ -0,0 +1,59 @@
+package crud
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextGetAll(t *testing.T) {
+	create_and_populate_test_db(30)
+	service := NewContextCrudService(contactsService)
+	result, err := service.GetAll(context.Background(), Paged(0, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, 30, result.TotalCount)
+	assert.Len(t, result.List, 10)
+}
+
+func TestContextCancelled(t *testing.T) {
+	create_and_populate_test_db(30)
+	service := NewContextCrudService(contactsService)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := service.FindOneByPublicId(ctx, crud_test_public_ids[0])
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, result)
+
+	_, err = service.Create(ctx, &TestContact{FullName: "NewCont"})
+	assert.ErrorIs(t, err, context.Canceled)
+	count, _ := contactsService.Count()
+	assert.Equal(t, 30, count)
+}
+
+func TestWithContextKeepsOriginalService(t *testing.T) {
+	create_and_populate_test_db(30)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := contactsService.WithContext(ctx).Count()
+	assert.ErrorIs(t, err, context.Canceled)
+
+	count, err := contactsService.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, 30, count)
+}
+
+func TestQueryTimeout(t *testing.T) {
+	create_and_populate_test_db(30)
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{QueryTimeout: time.Nanosecond},
+	)
+
+	_, err := service.GetAll()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -12,16 +12,33 @@ import (
 )
 
 type TestContact struct {
+	Id        int
+	FullName  string `validate:"required"`
+	PublicId  string `gorm:"index:idx_test_contacts_public_id,unique"`
+	Code      int    `validate:"gte=0"`
+	Email     string `validate:"omitempty,email"`
+	Phone     string
+	Tags      []TestTag `gorm:"many2many:test_contact_tags"`
+	Addresses []TestAddress
+}
+
+type TestTag struct {
 	Id       int
-	FullName string
-	PublicId string `gorm:"index:idx_test_contacts_public_id,unique"`
-	Code     int
-	Email    string
-	Phone    string
+	PublicId string `gorm:"index:idx_test_tags_public_id,unique"`
+	Name     string
+}
+
+type TestAddress struct {
+	Id            int
+	PublicId      string `gorm:"index:idx_test_addresses_public_id,unique"`
+	TestContactId int
+	City          string
 }
 
 var crud_test_db *gorm.DB
-var contactsService CrudService[TestContact, string]
+var contactsService ExtendedCrudService[TestContact, string]
+var tagsService ExtendedCrudService[TestTag, string]
+var addressesService ExtendedCrudService[TestAddress, string]
 var crud_test_public_ids []string
 
 func create_and_populate_test_db(seedDataLength int) {
@@ -30,7 +47,7 @@ func create_and_populate_test_db(seedDataLength int) {
 	if err != nil {
 		panic("Db connect failed: " + err.Error())
 	}
-	Db.AutoMigrate(&TestContact{})
+	Db.AutoMigrate(&TestContact{}, &TestTag{}, &TestAddress{})
 	contacts := make([]TestContact, 0)
 	crud_test_public_ids = make([]string, 0)
 	for i := 0; i < seedDataLength; i++ {
@@ -49,9 +66,20 @@ func create_and_populate_test_db(seedDataLength int) {
 		func(t TestContact) string { return t.PublicId },
 		func(t *TestContact, s string) { t.PublicId = s },
 		&CrudServiceOptions[TestContact, string]{
-			LookupQuery: "full_name like ? or email like ?",
+			LookupQuery:     "full_name like ? or email like ?",
+			AllowedPreloads: []string{"Tags", "Addresses"},
 		},
 	)
+	tagsService = NewCrudService(crud_test_db,
+		func(t TestTag) string { return t.PublicId },
+		func(t *TestTag, s string) { t.PublicId = s },
+		nil,
+	)
+	addressesService = NewCrudService(crud_test_db,
+		func(t TestAddress) string { return t.PublicId },
+		func(t *TestAddress, s string) { t.PublicId = s },
+		nil,
+	)
 }
 
 func TestCount(t *testing.T) {
@@ -326,3 +354,97 @@ func TestUpdateWhere(t *testing.T) {
 	crud_test_db.Model(&TestContact{}).Where("full_name like ?", "Cont-1%").Find(&items)
 	assert.Equal(t, 5, items[0].Code)
 }
+
+func TestUpdateAllWhere(t *testing.T) {
+	create_and_populate_test_db(30)
+	var items []TestContact
+	crud_test_db.Model(&TestContact{}).Where("full_name in ?", []string{"Cont-1", "Cont-2"}).Order("id").Find(&items)
+	for i := range items {
+		items[i].FullName += "_updated"
+	}
+
+	count, err := contactsService.UpdateAllWhere(items[:1], "full_name like ?", "Cont-1%")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// Cont-2 is not among the matching rows, so nothing is updated
+	count, err = contactsService.UpdateAllWhere(items, "full_name like ?", "Cont-1%")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 0, count)
+	var updated int64
+	crud_test_db.Model(&TestContact{}).Where("full_name like ?", "%_updated").Count(&updated)
+	assert.Equal(t, 1, int(updated))
+}
+
+type constantIdGenerator struct{ id string }
+
+func (g *constantIdGenerator) GetNewId() string { return g.id }
+
+func TestFindOneByPublicIdNotFound(t *testing.T) {
+	create_and_populate_test_db(30)
+	result, err := contactsService.FindOneByPublicId("non_existing_public_id")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, result)
+}
+
+func TestCreateConflict(t *testing.T) {
+	create_and_populate_test_db(30)
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{IdGenerator: &constantIdGenerator{id: crud_test_public_ids[0]}},
+	)
+	result, err := service.Create(&TestContact{FullName: "NewCont"})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Nil(t, result)
+}
+
+func TestFindAllWhereInvalidFilter(t *testing.T) {
+	create_and_populate_test_db(30)
+	result, err := contactsService.FindAllWhere("full_name like ?", "Cont-%", "not a filter")
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	assert.Nil(t, result)
+}
+
+func TestSortedGetAll(t *testing.T) {
+	create_and_populate_test_db(30)
+	crud_test_db.Exec("UPDATE test_contacts SET code = id % 3")
+
+	filter := Paged(0, 30)
+	filter.Sort = "code:desc,FullName:asc"
+	result, err := contactsService.GetAll(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.List[0].Code)
+	assert.Equal(t, "Cont-1", result.List[0].FullName)
+	assert.Equal(t, "Cont-10", result.List[1].FullName)
+	assert.Equal(t, 0, result.List[29].Code)
+}
+
+func TestSortRejectsUnknownColumns(t *testing.T) {
+	create_and_populate_test_db(30)
+	for _, sort := range []string{"(select 1)", "full_name desc", "unknown", "full_name:up", "code:desc;drop table test_contacts"} {
+		filter := Paged(0, 10)
+		filter.Sort = sort
+		result, err := contactsService.GetAll(filter)
+		assert.ErrorIs(t, err, ErrInvalidFilter, sort)
+		assert.Nil(t, result)
+	}
+
+	result, err := contactsService.GetAll(PagedAndSorted(0, 10, []SortInfo{{Column: "id; drop table test_contacts"}}))
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	assert.Nil(t, result)
+}
+
+func TestSortableColumns(t *testing.T) {
+	create_and_populate_test_db(30)
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{SortableColumns: []string{"full_name"}},
+	)
+	_, err := service.GetAll(PagedAndSorted(0, 10, []SortInfo{{Column: "FullName", Desc: true}}))
+	assert.Nil(t, err)
+
+	_, err = service.GetAll(PagedAndSorted(0, 10, []SortInfo{{Column: "email"}}))
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
@@ -0,0 +1,291 @@
+package crud
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// keysetColumn is a column of the keyset order of a cursor-paginated query.
+type keysetColumn struct {
+	Field *schema.Field
+	Desc  bool
+	// Nullable is set for pointer fields, whose NULL values are compared explicitly.
+	Nullable bool
+	// NullsLargest tells whether the database sorts NULLs after the other values in ascending order,
+	// as PostgreSQL does, rather than before them.
+	NullsLargest bool
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// newKeysetColumn returns the keyset column of the given field, or an ErrInvalidFilter if the NULLs
+// of the field cannot be told apart from its other values. The database is expected to hold no NULL
+// in the columns of the other fields, which read NULLs as their zero value.
+func newKeysetColumn(field *schema.Field, desc bool, isPublicId bool, dialect string) (keysetColumn, error) {
+	column := keysetColumn{Field: field, Desc: desc}
+	switch {
+	case isPublicId || field.PrimaryKey || field.NotNull:
+	case field.FieldType.Kind() == reflect.Pointer:
+		column.Nullable = true
+		column.NullsLargest = dialect == "postgres"
+	case field.FieldType.Implements(valuerType) || reflect.PointerTo(field.FieldType).Implements(valuerType):
+		return column, NewCrudError(ErrInvalidFilter, fmt.Sprintf("cannot page by cursor when sorting by '%s'", field.DBName))
+	}
+	return column, nil
+}
+
+// isNull tells whether the given cursor value of a nullable column is NULL.
+func isNull(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// equal matches the rows having the given value in the column.
+func (c keysetColumn) equal(value any) clause.Expression {
+	column := clause.Column{Name: c.Field.DBName}
+	if c.Nullable && isNull(value) {
+		return clause.Expr{SQL: "? IS NULL", Vars: []any{column}}
+	}
+	return clause.Eq{Column: column, Value: value}
+}
+
+// after matches the rows coming after the given value in the ascending order of the column.
+// It returns nil if there cannot be any.
+func (c keysetColumn) after(value any) clause.Expression {
+	column := clause.Column{Name: c.Field.DBName}
+	switch {
+	case !c.Nullable:
+		return clause.Gt{Column: column, Value: value}
+	case isNull(value) && c.NullsLargest:
+		return nil
+	case isNull(value):
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []any{column}}
+	case c.NullsLargest:
+		return clause.Expr{SQL: "(? > ? OR ? IS NULL)", Vars: []any{column, value, column}}
+	}
+	return clause.Gt{Column: column, Value: value}
+}
+
+// before matches the rows coming before the given value in the ascending order of the column.
+// It returns nil if there cannot be any.
+func (c keysetColumn) before(value any) clause.Expression {
+	column := clause.Column{Name: c.Field.DBName}
+	switch {
+	case !c.Nullable:
+		return clause.Lt{Column: column, Value: value}
+	case isNull(value) && c.NullsLargest:
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []any{column}}
+	case isNull(value):
+		return nil
+	case c.NullsLargest:
+		return clause.Lt{Column: column, Value: value}
+	}
+	return clause.Expr{SQL: "(? < ? OR ? IS NULL)", Vars: []any{column, value, column}}
+}
+
+func (c keysetColumn) key() string {
+	if c.Desc {
+		return "-" + c.Field.DBName
+	}
+	return c.Field.DBName
+}
+
+// cursorData is the content of an opaque cursor: the keyset columns and the values of a row for them.
+type cursorData struct {
+	Keys   []string `json:"k"`
+	Values []any    `json:"v"`
+}
+
+// encodeCursor returns the cursor pointing at the given entity in the given keyset order.
+func encodeCursor[T any](columns []keysetColumn, entity *T) (string, error) {
+	data := cursorData{Keys: make([]string, len(columns)), Values: make([]any, len(columns))}
+	rv := reflect.ValueOf(entity).Elem()
+	for i, column := range columns {
+		data.Keys[i] = column.key()
+		data.Values[i], _ = column.Field.ValueOf(context.Background(), rv)
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(content), nil
+}
+
+func invalidCursor() *CrudError {
+	return NewCrudError(ErrInvalidFilter, "invalid cursor")
+}
+
+// decodeCursor returns the values of the given cursor, which must have been encoded for the same keyset order.
+func decodeCursor(columns []keysetColumn, cursor string) ([]any, error) {
+	content, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalidCursor()
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var data cursorData
+	if err := decoder.Decode(&data); err != nil || len(data.Keys) != len(columns) || len(data.Values) != len(columns) {
+		return nil, invalidCursor()
+	}
+	for i, column := range columns {
+		if data.Keys[i] != column.key() {
+			return nil, NewCrudError(ErrInvalidFilter, "the cursor does not match the sort of the query")
+		}
+		value := data.Values[i]
+		if number, ok := value.(json.Number); ok {
+			value = number.String()
+		}
+		if value, err = coerceValue(column.Field, value); err != nil {
+			return nil, invalidCursor()
+		}
+		data.Values[i] = value
+	}
+	return data.Values, nil
+}
+
+// keysetCondition matches the rows after (or before when backward is set) the row with the given values
+// in the keyset order, i.e. (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ... for ascending columns.
+func keysetCondition(columns []keysetColumn, values []any, backward bool) clause.Expression {
+	alternatives := make([]clause.Expression, 0, len(columns))
+	for i, column := range columns {
+		var next clause.Expression
+		if column.Desc != backward {
+			next = column.before(values[i])
+		} else {
+			next = column.after(values[i])
+		}
+		if next == nil {
+			continue
+		}
+		conditions := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, columns[j].equal(values[j]))
+		}
+		alternatives = append(alternatives, clause.And(append(conditions, next)...))
+	}
+	if len(alternatives) == 0 {
+		return clause.Expr{SQL: "1 = 0"}
+	}
+	return clause.Or(alternatives...)
+}
+
+// keysetOrderBy returns the order of the given keyset columns, reversed when backward is set.
+// The columns are sorted as they are, NULLs where the database puts them, so that their indexes can serve the order.
+func keysetOrderBy(columns []keysetColumn, backward bool) clause.OrderBy {
+	result := clause.OrderBy{Columns: make([]clause.OrderByColumn, len(columns))}
+	for i, column := range columns {
+		result.Columns[i] = clause.OrderByColumn{Column: clause.Column{Name: column.Field.DBName}, Desc: column.Desc != backward}
+	}
+	return result
+}
+
+// getKeysetColumns returns the sort columns of the query followed by the public id, which makes the order total.
+func (service *CrudServiceImpl[T, TPublicId]) getKeysetColumns(orderBy []clause.OrderByColumn) ([]keysetColumn, error) {
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	publicIdField := lookUpColumn(sch, service._options.PublicIdColumnName)
+	if publicIdField == nil {
+		return nil, fmt.Errorf("public id column '%s' not found", service._options.PublicIdColumnName)
+	}
+	result := make([]keysetColumn, 0, len(orderBy)+1)
+	hasPublicId := false
+	for _, column := range orderBy {
+		isPublicId := column.Column.Name == publicIdField.DBName
+		keysetColumn, err := newKeysetColumn(sch.LookUpField(column.Column.Name), column.Desc, isPublicId, service._db.Dialector.Name())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, keysetColumn)
+		hasPublicId = hasPublicId || isPublicId
+	}
+	if !hasPublicId {
+		result = append(result, keysetColumn{Field: publicIdField})
+	}
+	return result, nil
+}
+
+// findByKeyset returns the page of entities right after (or before) the cursor of the filter.
+// Counting by window is not possible as the count should not be bound to the cursor, so it counts exactly instead.
+func (service *CrudServiceImpl[T, TPublicId]) findByKeyset(query *gorm.DB, filter *DataFilter, orderBy []clause.OrderByColumn, selectedColumns []string, preloads []string, strategy CountStrategy) (*PagedList[T], error) {
+	if len(filter.After) > 0 && len(filter.Before) > 0 {
+		return nil, NewCrudError(ErrInvalidFilter, "'after' and 'before' cannot be used together")
+	}
+	columns, err := service.getKeysetColumns(orderBy)
+	if err != nil {
+		return nil, err
+	}
+	backward := len(filter.Before) > 0
+	pageQuery := query
+	if cursor := filter.After + filter.Before; len(cursor) > 0 {
+		values, err := decodeCursor(columns, cursor)
+		if err != nil {
+			return nil, err
+		}
+		pageQuery = pageQuery.Where(keysetCondition(columns, values, backward))
+	}
+	if selectedColumns != nil {
+		// The keyset columns are needed to make the cursors
+		keysetFields := make([]*schema.Field, len(columns))
+		for i, column := range columns {
+			keysetFields[i] = column.Field
+		}
+		pageQuery = pageQuery.Select(columnNames(keysetFields, selectedColumns...))
+	}
+	pageQuery = pageQuery.Clauses(keysetOrderBy(columns, backward))
+
+	var resultList []T
+	if err := applyPreloads(pageQuery, preloads).Limit(filter.Limit + 1).Find(&resultList).Error; err != nil {
+		return nil, translateError(err)
+	}
+	hasMore := len(resultList) > filter.Limit
+	if hasMore {
+		resultList = resultList[:filter.Limit]
+	}
+	if backward {
+		for i, j := 0, len(resultList)-1; i < j; i, j = i+1, j-1 {
+			resultList[i], resultList[j] = resultList[j], resultList[i]
+		}
+	}
+
+	var totalCount int64
+	countExact := false
+	if strategy != CountSkip {
+		if totalCount, countExact, err = service.countTotal(query, strategy); err != nil {
+			return nil, err
+		}
+	}
+	result := NewPagedList(resultList, int(totalCount), &DataFilter{Limit: filter.Limit})
+	result.CountExact = countExact
+	if backward {
+		result.HasNext, result.HasPrevious = true, hasMore
+	} else {
+		result.HasNext, result.HasPrevious = hasMore, len(filter.After) > 0
+	}
+	if len(resultList) > 0 {
+		if result.HasNext {
+			if result.NextCursor, err = encodeCursor(columns, &resultList[len(resultList)-1]); err != nil {
+				return nil, err
+			}
+		}
+		if result.HasPrevious {
+			if result.PrevCursor, err = encodeCursor(columns, &resultList[0]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,171 @@
+package crud
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestTask struct {
+	Id       int
+	PublicId string `gorm:"index:idx_test_tasks_public_id,unique"`
+	Title    string
+	Priority *int
+	Done     sql.NullBool
+}
+
+func codesOf(list []TestContact) []int {
+	result := make([]int, len(list))
+	for i, c := range list {
+		result[i] = c.Code
+	}
+	return result
+}
+
+func TestCursorPaging(t *testing.T) {
+	create_and_populate_coded_test_db()
+
+	filter := &DataFilter{Limit: 12, Cursor: true, Sort: "code:desc"}
+	filter.FindBy = map[string]any{"code": Gte(2)}
+	first, err := contactsService.GetAll(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, 28, first.TotalCount)
+	assert.Equal(t, 29, first.List[0].Code)
+	assert.Equal(t, 18, first.List[11].Code)
+	assert.True(t, first.HasNext)
+	assert.False(t, first.HasPrevious)
+	assert.Empty(t, first.PrevCursor)
+
+	second, err := contactsService.GetAll(&DataFilter{Limit: 12, Sort: "code:desc", After: first.NextCursor,
+		FindBy: map[string]any{"code": Gte(2)}})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6}, codesOf(second.List))
+	assert.True(t, second.HasNext)
+	assert.True(t, second.HasPrevious)
+
+	last, err := contactsService.GetAll(&DataFilter{Limit: 12, Sort: "code:desc", After: second.NextCursor,
+		FindBy: map[string]any{"code": Gte(2)}})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{5, 4, 3, 2}, codesOf(last.List))
+	assert.False(t, last.HasNext)
+	assert.Empty(t, last.NextCursor)
+
+	back, err := contactsService.GetAll(&DataFilter{Limit: 12, Sort: "code:desc", Before: last.PrevCursor,
+		FindBy: map[string]any{"code": Gte(2)}})
+	assert.Nil(t, err)
+	assert.Equal(t, codesOf(second.List), codesOf(back.List))
+	assert.True(t, back.HasPrevious)
+	assert.True(t, back.HasNext)
+
+	back, err = contactsService.GetAll(&DataFilter{Limit: 12, Sort: "code:desc", Before: back.PrevCursor,
+		FindBy: map[string]any{"code": Gte(2)}})
+	assert.Nil(t, err)
+	assert.Equal(t, codesOf(first.List), codesOf(back.List))
+	assert.False(t, back.HasPrevious)
+}
+
+func TestCursorPagingTieBreaker(t *testing.T) {
+	// All the contacts have the same code, so only the public id orders them
+	create_and_populate_test_db(7)
+	seen := make(map[string]bool)
+	filter := &DataFilter{Limit: 2, Cursor: true, Sort: "code"}
+	for {
+		result, err := contactsService.GetAll(filter)
+		assert.Nil(t, err)
+		for _, c := range result.List {
+			assert.False(t, seen[c.PublicId])
+			seen[c.PublicId] = true
+		}
+		if !result.HasNext {
+			break
+		}
+		filter = &DataFilter{Limit: 2, Sort: "code", After: result.NextCursor}
+	}
+	assert.Len(t, seen, 7)
+}
+
+func TestInvalidCursor(t *testing.T) {
+	create_and_populate_test_db(5)
+	result, err := contactsService.GetAll(&DataFilter{Limit: 2, Cursor: true})
+	assert.Nil(t, err)
+
+	for _, filter := range []*DataFilter{
+		{After: "not a cursor"},
+		{After: "e30"},
+		{After: result.NextCursor, Sort: "code:desc"},
+		{After: result.NextCursor, Before: result.NextCursor},
+	} {
+		_, err = contactsService.GetAll(filter)
+		assert.True(t, errors.Is(err, ErrInvalidFilter), filter)
+	}
+}
+
+// pageAll walks through the pages of the given sort forward, then back from the last page, returning the ids
+// in the order they were found in each walk.
+func pageAll[T any](t *testing.T, service CrudService[T, string], idOf func(T) int, sort string) ([]int, []int) {
+	var forward, backward []int
+	result, err := service.GetAll(&DataFilter{Limit: 4, Cursor: true, Sort: sort})
+	for ; err == nil && len(forward) <= 100; result, err = service.GetAll(&DataFilter{Limit: 4, Sort: sort, After: result.NextCursor}) {
+		for _, e := range result.List {
+			forward = append(forward, idOf(e))
+		}
+		if !result.HasNext {
+			break
+		}
+	}
+	assert.Nil(t, err)
+	for ; err == nil && len(backward) <= 100; result, err = service.GetAll(&DataFilter{Limit: 4, Sort: sort, Before: result.PrevCursor}) {
+		for i := len(result.List) - 1; i >= 0; i-- {
+			backward = append(backward, idOf(result.List[i]))
+		}
+		if !result.HasPrevious {
+			break
+		}
+	}
+	assert.Nil(t, err)
+	return forward, backward
+}
+
+func reversed(ids []int) []int {
+	result := make([]int, len(ids))
+	for i, id := range ids {
+		result[len(ids)-1-i] = id
+	}
+	return result
+}
+
+func TestCursorPagingNullablePointers(t *testing.T) {
+	create_and_populate_test_db(0)
+	crud_test_db.AutoMigrate(&TestTask{})
+	service := NewCrudService(crud_test_db,
+		func(t TestTask) string { return t.PublicId },
+		func(t *TestTask, s string) { t.PublicId = s },
+		nil,
+	)
+	for i := 0; i < 10; i++ {
+		task := &TestTask{Title: fmt.Sprintf("Task-%d", i)}
+		if i%3 != 0 {
+			priority := i % 4
+			task.Priority = &priority
+		}
+		_, err := service.Create(task)
+		assert.Nil(t, err)
+	}
+
+	for _, sort := range []string{"priority", "priority:desc", "priority,title:desc"} {
+		forward, backward := pageAll[TestTask](t, service, func(t TestTask) int { return t.Id }, sort)
+		assert.Len(t, forward, 10, sort)
+		assert.Equal(t, forward, reversed(backward), sort)
+	}
+	// SQLite puts NULLs first in ascending order
+	result, err := service.GetAll(&DataFilter{Limit: 100, Cursor: true, Sort: "priority"})
+	assert.Nil(t, err)
+	assert.Nil(t, result.List[0].Priority)
+	assert.NotNil(t, result.List[9].Priority)
+
+	_, err = service.GetAll(&DataFilter{Limit: 4, Cursor: true, Sort: "done"})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
@@ -0,0 +1,103 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrValidation    = errors.New("validation failed")
+	ErrConflict      = errors.New("conflict")
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrInvalidId     = errors.New("invalid id")
+	// ErrInvalidOptions is the kind of errors of misconfigured service options, found when they are used.
+	ErrInvalidOptions = errors.New("invalid options")
+)
+
+// FieldError describes a problem with a single field of an entity.
+type FieldError struct {
+	// Index is the position of the entity within the batch being written.
+	Index   int    `json:"index"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// CrudError is a structured error of one of the Err* kinds above.
+// It matches its kind with errors.Is and unwraps to its cause.
+type CrudError struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func NewCrudError(kind error, message string) *CrudError {
+	return &CrudError{Kind: kind, Message: message}
+}
+
+func (e *CrudError) Error() string {
+	message := e.Message
+	if len(message) == 0 {
+		message = e.Kind.Error()
+	}
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *CrudError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *CrudError) Unwrap() error {
+	return e.Err
+}
+
+// VersionConflictError is the ErrConflict of an update made to an entity changed since it was read,
+// i.e. whose version column no longer holds the version of the update.
+type VersionConflictError struct {
+	PublicId       any
+	CurrentVersion any
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("'%v' was changed by another update, its current version is %v", e.PublicId, e.CurrentVersion)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+var uniqueViolationMessages = []string{
+	"unique constraint failed",    // sqlite
+	"violates unique constraint",  // postgres
+	"sqlstate 23505",              // postgres
+	"duplicate entry",             // mysql
+	"cannot insert duplicate key", // sql server
+}
+
+// translateError converts errors reported by gorm and the database drivers into CrudErrors.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var crudErr *CrudError
+	if errors.As(err, &crudErr) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &CrudError{Kind: ErrNotFound, Err: err}
+	}
+	message := strings.ToLower(err.Error())
+	for _, m := range uniqueViolationMessages {
+		if strings.Contains(message, m) {
+			return &CrudError{Kind: ErrConflict, Message: "entity already exists", Err: err}
+		}
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCrudErrorIs(t *testing.T) {
+	cause := errors.New("cause")
+	err := &CrudError{Kind: ErrConflict, Message: "entity already exists", Err: cause}
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "entity already exists: cause", err.Error())
+	assert.Equal(t, "not found", NewCrudError(ErrNotFound, "").Error())
+}
+
+func TestVersionConflictError(t *testing.T) {
+	err := translateError(&VersionConflictError{PublicId: "e61bc045", CurrentVersion: 3})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "'e61bc045' was changed by another update, its current version is 3", err.Error())
+	assert.Equal(t, 3, NewErrorResponse(err).CurrentVersion)
+}
+
+func TestTranslateError(t *testing.T) {
+	assert.Nil(t, translateError(nil))
+	assert.ErrorIs(t, translateError(gorm.ErrRecordNotFound), ErrNotFound)
+	assert.ErrorIs(t, translateError(errors.New("UNIQUE constraint failed: contacts.public_id")), ErrConflict)
+	assert.ErrorIs(t, translateError(errors.New(`ERROR: duplicate key value violates unique constraint "idx" (SQLSTATE 23505)`)), ErrConflict)
+	other := errors.New("no such table: contacts")
+	assert.Equal(t, other, translateError(other))
+}
@@ -0,0 +1,368 @@
+package crud
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// ExportFormat is the format of the files written by Export.
+type ExportFormat string
+
+const (
+	ExportCsv  ExportFormat = "csv"
+	ExportXlsx ExportFormat = "xlsx"
+)
+
+const defaultExportBatchSize = 500
+
+// ExportColumn is a column of an exported file.
+type ExportColumn struct {
+	// Field is the column, field or JSON name of the exported field.
+	Field string
+	// Header is the title of the column, the JSON name of the field by default.
+	Header string
+	// Format converts the values of the field to text. By default, times are written in RFC 3339 format
+	// and the other values as they are printed by fmt, except numbers and booleans which are kept as such in XLSX.
+	// Texts starting with =, +, -, @, a tab or a carriage return are prefixed with a quote, so that they are not run as formulas.
+	Format func(value any) string
+}
+
+type ExportOptions struct {
+	Format ExportFormat
+	// Columns are the exported columns in their order, all the fields having a JSON name by default.
+	Columns []ExportColumn
+	// BatchSize is the number of entities read per query, 500 by default.
+	BatchSize int
+}
+
+// exportColumn is an ExportColumn resolved against the schema of the entity.
+type exportColumn struct {
+	ExportColumn
+	field *schema.Field
+}
+
+// exportWriter writes the rows of an exported file.
+type exportWriter interface {
+	WriteRow(values []any, columns []exportColumn) error
+	Close() error
+}
+
+// resolveExportColumns returns the given columns, or all the fields having a JSON name if there are none,
+// with their headers defaulted.
+func resolveExportColumns(sch *schema.Schema, columns []ExportColumn) ([]exportColumn, error) {
+	if len(columns) == 0 {
+		for _, field := range sch.Fields {
+			if len(field.DBName) > 0 && len(jsonName(field)) > 0 {
+				columns = append(columns, ExportColumn{Field: field.DBName})
+			}
+		}
+	}
+	result := make([]exportColumn, len(columns))
+	for i, column := range columns {
+		field := lookUpColumn(sch, column.Field)
+		if field == nil {
+			return nil, invalidFilter("cannot export '%s'", column.Field)
+		}
+		if len(column.Header) == 0 {
+			column.Header = jsonName(field)
+			if len(column.Header) == 0 {
+				column.Header = field.Name
+			}
+		}
+		result[i] = exportColumn{ExportColumn: column, field: field}
+	}
+	return result, nil
+}
+
+// exportValue returns the value of an exported cell, dereferencing pointers and turning nil ones into nil.
+// Values such as sql.NullString are replaced by the value they are stored as.
+func exportValue(value any) any {
+	if valuer, ok := value.(driver.Valuer); ok {
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil
+		}
+		if stored, err := valuer.Value(); err == nil {
+			value = stored
+		}
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// formatExportValue converts the given cell value to text with the formatter of its column, if any.
+// Texts are escaped, so that spreadsheets do not run them as formulas.
+func formatExportValue(value any, column exportColumn) string {
+	if column.Format != nil {
+		return escapeFormula(column.Format(value))
+	}
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	if reflect.ValueOf(value).Kind() == reflect.String {
+		return escapeFormula(fmt.Sprint(value))
+	}
+	return fmt.Sprint(value)
+}
+
+// escapeFormula prefixes the given text with a quote if it starts with a character spreadsheets
+// would read it as a formula by, see https://owasp.org/www-community/attacks/CSV_Injection.
+func escapeFormula(text string) string {
+	if len(text) > 0 && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (w *csvExportWriter) WriteRow(values []any, columns []exportColumn) error {
+	w.record = w.record[:0]
+	for i, value := range values {
+		w.record = append(w.record, formatExportValue(value, columns[i]))
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *csvExportWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// xlsxExportWriter writes a workbook with a single sheet, streaming its rows.
+// Strings are written inline, so the workbook needs no shared strings table.
+type xlsxExportWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
+
+const xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const xlsxSheetEnd = `</sheetData></worksheet>`
+
+func newXlsxExportWriter(writer io.Writer, sheetName string) (*xlsxExportWriter, error) {
+	if len(sheetName) > 31 {
+		sheetName = sheetName[:31]
+	}
+	var escapedName xmlText
+	if err := xml.EscapeText(&escapedName, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+	w := &xlsxExportWriter{zip: zip.NewWriter(writer)}
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, string(escapedName))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		partWriter, err := w.zip.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(partWriter, part.content); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := w.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+	w.sheet = sheet
+	return w, nil
+}
+
+// xmlText collects escaped XML text.
+type xmlText []byte
+
+func (t *xmlText) Write(p []byte) (int, error) {
+	*t = append(*t, p...)
+	return len(p), nil
+}
+
+// xlsxColumnName returns the letters of the column with the given zero based index, e.g. A, Z, AA.
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+func (w *xlsxExportWriter) WriteRow(values []any, columns []exportColumn) error {
+	w.row++
+	var buffer xmlText
+	buffer = append(buffer, fmt.Sprintf(`<row r="%d">`, w.row)...)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + strconv.Itoa(w.row)
+		if value == nil && columns[i].Format == nil {
+			continue
+		}
+		if columns[i].Format == nil {
+			switch v := reflect.ValueOf(value); v.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				buffer = append(buffer, fmt.Sprintf(`<c r="%s"><v>%v</v></c>`, ref, value)...)
+				continue
+			case reflect.Bool:
+				b := 0
+				if v.Bool() {
+					b = 1
+				}
+				buffer = append(buffer, fmt.Sprintf(`<c r="%s" t="b"><v>%d</v></c>`, ref, b)...)
+				continue
+			}
+		}
+		buffer = append(buffer, fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)...)
+		if err := xml.EscapeText(&buffer, []byte(formatExportValue(value, columns[i]))); err != nil {
+			return err
+		}
+		buffer = append(buffer, `</t></is></c>`...)
+	}
+	buffer = append(buffer, `</row>`...)
+	_, err := w.sheet.Write(buffer)
+	return err
+}
+
+func (w *xlsxExportWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+// Export writes the entities of the given service matching the filter to the given writer as a CSV or XLSX file,
+// with a header row followed by a row per entity. The FindBy and sort of the filter apply, paging does not:
+// the entities are read in batches, so that memory use does not depend on their number.
+// Nothing is written if the filter or the columns are invalid.
+func Export[T any, TPublicId any](ctx context.Context, crudService ExtendedCrudService[T, TPublicId], writer io.Writer, filter *DataFilter, options ExportOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(options.Format) == 0 {
+		options.Format = ExportCsv
+	}
+	if options.Format != ExportCsv && options.Format != ExportXlsx {
+		return invalidFilter("unsupported export format '%s'", options.Format)
+	}
+	sch, err := crudService.GetSchema()
+	if err != nil {
+		return err
+	}
+	columns, err := resolveExportColumns(sch, options.Columns)
+	if err != nil {
+		return err
+	}
+	exportFilter := &DataFilter{}
+	if filter != nil {
+		*exportFilter = *filter
+	}
+	exportFilter.Include = nil
+	exportFilter.Fields = make([]string, len(columns))
+	for i, column := range columns {
+		exportFilter.Fields[i] = column.field.DBName
+	}
+	batchSize := options.BatchSize
+	if batchSize < 1 {
+		batchSize = defaultExportBatchSize
+	}
+
+	var rows exportWriter
+	start := func() error {
+		if options.Format == ExportXlsx {
+			xlsxRows, err := newXlsxExportWriter(writer, sch.Table)
+			if err != nil {
+				return err
+			}
+			rows = xlsxRows
+		} else {
+			rows = &csvExportWriter{writer: csv.NewWriter(writer)}
+		}
+		headers := make([]any, len(columns))
+		for i, column := range columns {
+			headers[i] = column.Header
+		}
+		return rows.WriteRow(headers, make([]exportColumn, len(columns)))
+	}
+	values := make([]any, len(columns))
+	err = crudService.Iterate(ctx, exportFilter, batchSize, func(batch []T) error {
+		if rows == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for i := range batch {
+			entity := reflect.ValueOf(&batch[i]).Elem()
+			for j, column := range columns {
+				value, _ := column.field.ValueOf(ctx, entity)
+				values[j] = exportValue(value)
+			}
+			if err := rows.WriteRow(values, columns); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if rows == nil {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	return rows.Close()
+}
@@ -0,0 +1,133 @@
+package crud
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCsv(t *testing.T) {
+	create_and_populate_coded_test_db()
+
+	var buffer bytes.Buffer
+	filter := &DataFilter{FindBy: map[string]any{"code": Lt(7)}, Sort: "code:desc", Limit: 2}
+	err := Export(context.Background(), contactsService, &buffer, filter, ExportOptions{
+		Format:    ExportCsv,
+		BatchSize: 3,
+		Columns: []ExportColumn{
+			{Field: "code", Header: "Code #"},
+			{Field: "FullName"},
+			{Field: "email", Format: func(value any) string { return fmt.Sprintf("<%v>", value) }},
+		},
+	})
+	assert.Nil(t, err)
+	records, err := csv.NewReader(&buffer).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 8)
+	assert.Equal(t, []string{"Code #", "FullName", "Email"}, records[0])
+	assert.Equal(t, []string{"6", "Cont-6", "<c_6@gmail.com>"}, records[1])
+	assert.Equal(t, []string{"0", "Cont-0", "<>"}, records[7])
+
+	// All the fields are exported by default
+	buffer.Reset()
+	err = Export(context.Background(), contactsService, &buffer, &DataFilter{FindBy: map[string]any{"code": 3}}, ExportOptions{})
+	assert.Nil(t, err)
+	records, _ = csv.NewReader(&buffer).ReadAll()
+	assert.Equal(t, []string{"Id", "FullName", "PublicId", "Code", "Email", "Phone"}, records[0])
+	assert.Equal(t, "Cont-3", records[1][1])
+}
+
+func TestExportXlsx(t *testing.T) {
+	create_and_populate_coded_test_db()
+
+	var buffer bytes.Buffer
+	err := Export(context.Background(), contactsService, &buffer, &DataFilter{Sort: "code"}, ExportOptions{
+		Format:  ExportXlsx,
+		Columns: []ExportColumn{{Field: "full_name", Header: "Name & Title"}, {Field: "code"}},
+	})
+	assert.Nil(t, err)
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.Nil(t, err)
+	names := make([]string, 0)
+	var sheet string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			reader, _ := file.Open()
+			content, _ := io.ReadAll(reader)
+			sheet = string(content)
+		}
+	}
+	assert.Contains(t, names, "[Content_Types].xml")
+	assert.Contains(t, names, "xl/workbook.xml")
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">Name &amp; Title</t></is></c>`)
+	assert.Contains(t, sheet, `<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve">Cont-0</t></is></c><c r="B2"><v>0</v></c></row>`)
+	assert.Contains(t, sheet, `<row r="31">`)
+	assert.NotContains(t, sheet, `<row r="32">`)
+}
+
+func TestExportErrors(t *testing.T) {
+	create_and_populate_test_db(3)
+
+	var buffer bytes.Buffer
+	err := Export(context.Background(), contactsService, &buffer, nil, ExportOptions{Format: "pdf"})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	err = Export(context.Background(), contactsService, &buffer, nil, ExportOptions{Columns: []ExportColumn{{Field: "nickname"}}})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	err = Export(context.Background(), contactsService, &buffer, &DataFilter{Sort: "nickname"}, ExportOptions{})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	assert.Equal(t, 0, buffer.Len())
+
+	// Nothing to export still writes the header row
+	err = Export(context.Background(), contactsService, &buffer, &DataFilter{FindBy: map[string]any{"code": 100}}, ExportOptions{
+		Columns: []ExportColumn{{Field: "full_name"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "FullName\n", buffer.String())
+}
+
+func TestXlsxColumnName(t *testing.T) {
+	assert.Equal(t, "A", xlsxColumnName(0))
+	assert.Equal(t, "Z", xlsxColumnName(25))
+	assert.Equal(t, "AA", xlsxColumnName(26))
+	assert.Equal(t, "AZ", xlsxColumnName(51))
+	assert.Equal(t, "BA", xlsxColumnName(52))
+}
+
+func TestExportEscapesFormulas(t *testing.T) {
+	create_and_populate_coded_test_db()
+	crud_test_db.Exec("UPDATE test_contacts SET full_name = '=HYPERLINK(\"http://x\")', phone = '+251 11' WHERE code = 1")
+	crud_test_db.Exec("UPDATE test_contacts SET full_name = '@SUM(A1)', phone = '-1' WHERE code = 2")
+	crud_test_db.Exec("UPDATE test_contacts SET code = -3 WHERE code = 3")
+	filter := &DataFilter{FindBy: map[string]any{"id": In(2, 3, 4)}, Sort: "id"}
+	columns := []ExportColumn{{Field: "full_name"}, {Field: "phone"}, {Field: "code"}, {Field: "email", Format: func(any) string { return "\tx" }}}
+
+	var buffer bytes.Buffer
+	err := Export(context.Background(), contactsService, &buffer, filter, ExportOptions{Columns: columns})
+	assert.Nil(t, err)
+	records, _ := csv.NewReader(&buffer).ReadAll()
+	assert.Equal(t, []string{`'=HYPERLINK("http://x")`, "'+251 11", "1", "'\tx"}, records[1])
+	assert.Equal(t, []string{"'@SUM(A1)", "'-1", "2", "'\tx"}, records[2])
+	// Numbers are not texts, so negative ones are kept as they are
+	assert.Equal(t, "-3", records[3][2])
+
+	buffer.Reset()
+	err = Export(context.Background(), contactsService, &buffer, filter, ExportOptions{Format: ExportXlsx, Columns: columns})
+	assert.Nil(t, err)
+	archive, _ := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			reader, _ := file.Open()
+			sheet, _ := io.ReadAll(reader)
+			assert.Contains(t, string(sheet), `<t xml:space="preserve">&#39;@SUM(A1)</t>`)
+			assert.Contains(t, string(sheet), `<c r="C4"><v>-3</v></c>`)
+		}
+	}
+}
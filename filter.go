@@ -0,0 +1,302 @@
+package crud
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// FilterOperator is a comparison used in DataFilter.FindBy conditions.
+type FilterOperator string
+
+const (
+	FilterEq         FilterOperator = "eq"
+	FilterNe         FilterOperator = "ne"
+	FilterGt         FilterOperator = "gt"
+	FilterGte        FilterOperator = "gte"
+	FilterLt         FilterOperator = "lt"
+	FilterLte        FilterOperator = "lte"
+	FilterIn         FilterOperator = "in"
+	FilterNotIn      FilterOperator = "not-in"
+	FilterLike       FilterOperator = "like"
+	FilterStartsWith FilterOperator = "starts-with"
+	FilterBetween    FilterOperator = "between"
+	FilterIsNull     FilterOperator = "is-null"
+)
+
+// Keys of DataFilter.FindBy holding a list of nested FindBy maps ([]map[string]any)
+// that should all or any match respectively.
+const (
+	FindByAnd = "$and"
+	FindByOr  = "$or"
+)
+
+// Condition compares a column with a value.
+// Between expects a two items slice, In and NotIn a slice and IsNull a bool value.
+// Like patterns escape their wildcards, and '!' itself, with '!'.
+type Condition struct {
+	Operator FilterOperator
+	Value    any
+}
+
+func Eq(value any) Condition        { return Condition{Operator: FilterEq, Value: value} }
+func Ne(value any) Condition        { return Condition{Operator: FilterNe, Value: value} }
+func Gt(value any) Condition        { return Condition{Operator: FilterGt, Value: value} }
+func Gte(value any) Condition       { return Condition{Operator: FilterGte, Value: value} }
+func Lt(value any) Condition        { return Condition{Operator: FilterLt, Value: value} }
+func Lte(value any) Condition       { return Condition{Operator: FilterLte, Value: value} }
+func In(values ...any) Condition    { return Condition{Operator: FilterIn, Value: values} }
+func NotIn(values ...any) Condition { return Condition{Operator: FilterNotIn, Value: values} }
+func Like(pattern string) Condition { return Condition{Operator: FilterLike, Value: pattern} }
+func StartsWith(prefix string) Condition {
+	return Condition{Operator: FilterStartsWith, Value: prefix}
+}
+func Between(from any, to any) Condition {
+	return Condition{Operator: FilterBetween, Value: []any{from, to}}
+}
+func IsNull(isNull bool) Condition { return Condition{Operator: FilterIsNull, Value: isNull} }
+
+func invalidFilter(format string, args ...any) *CrudError {
+	return NewCrudError(ErrInvalidFilter, fmt.Sprintf(format, args...))
+}
+
+// lookUpColumn finds the database column of the given schema by its column, field or JSON name.
+func lookUpColumn(sch *schema.Schema, name string) *schema.Field {
+	field := sch.LookUpField(name)
+	if field == nil {
+		for _, f := range sch.Fields {
+			if jsonName(f) == name {
+				field = f
+				break
+			}
+		}
+	}
+	if field == nil || len(field.DBName) == 0 {
+		return nil
+	}
+	return field
+}
+
+// jsonName returns the name of the field in the JSON encoding of its entity.
+func jsonName(field *schema.Field) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if len(name) == 0 {
+		return field.Name
+	}
+	return name
+}
+
+// buildFindByConditions converts a FindBy map into where-clause expressions,
+// rejecting columns that are not part of the model.
+func buildFindByConditions(sch *schema.Schema, findBy map[string]any) ([]clause.Expression, error) {
+	keys := make([]string, 0, len(findBy))
+	for key := range findBy {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]clause.Expression, 0, len(keys))
+	for _, key := range keys {
+		value := findBy[key]
+		if key == FindByAnd || key == FindByOr {
+			groups, ok := value.([]map[string]any)
+			if !ok {
+				return nil, invalidFilter("'%s' should be a list of conditions", key)
+			}
+			exprs := make([]clause.Expression, 0, len(groups))
+			for _, group := range groups {
+				conditions, err := buildFindByConditions(sch, group)
+				if err != nil {
+					return nil, err
+				}
+				if len(conditions) > 0 {
+					exprs = append(exprs, clause.And(conditions...))
+				}
+			}
+			if len(exprs) == 0 {
+				continue
+			}
+			if key == FindByAnd {
+				result = append(result, clause.And(exprs...))
+			} else {
+				result = append(result, clause.Or(exprs...))
+			}
+			continue
+		}
+
+		field := lookUpColumn(sch, key)
+		if field == nil {
+			return nil, invalidFilter("unknown filter column '%s'", key)
+		}
+		var conditions []Condition
+		switch v := value.(type) {
+		case Condition:
+			conditions = []Condition{v}
+		case []Condition:
+			conditions = v
+		default:
+			conditions = []Condition{Eq(v)}
+		}
+		for _, condition := range conditions {
+			expr, err := buildCondition(field, condition)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, expr)
+		}
+	}
+	return result, nil
+}
+
+func toSlice(value any) ([]any, bool) {
+	if values, ok := value.([]any); ok {
+		return values, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns with '!' rather than a backslash,
+// which string literals of some databases (e.g. MySQL) treat as an escape of their own.
+var likeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+func buildCondition(field *schema.Field, condition Condition) (clause.Expression, error) {
+	column := clause.Column{Name: field.DBName}
+	switch condition.Operator {
+	case FilterIn, FilterNotIn:
+		values, ok := toSlice(condition.Value)
+		if !ok {
+			return nil, invalidFilter("'%s' on '%s' expects a list of values", condition.Operator, field.DBName)
+		}
+		for i := range values {
+			value, err := coerceValue(field, values[i])
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		if condition.Operator == FilterNotIn {
+			return clause.Not(clause.IN{Column: column, Values: values}), nil
+		}
+		return clause.IN{Column: column, Values: values}, nil
+	case FilterBetween:
+		values, ok := toSlice(condition.Value)
+		if !ok || len(values) != 2 {
+			return nil, invalidFilter("'%s' on '%s' expects two values", condition.Operator, field.DBName)
+		}
+		from, err := coerceValue(field, values[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := coerceValue(field, values[1])
+		if err != nil {
+			return nil, err
+		}
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []any{column, from, to}}, nil
+	case FilterIsNull:
+		isNull, ok := condition.Value.(bool)
+		if !ok {
+			parsed, err := strconv.ParseBool(fmt.Sprint(condition.Value))
+			if err != nil {
+				return nil, invalidFilter("'%s' on '%s' expects true or false", condition.Operator, field.DBName)
+			}
+			isNull = parsed
+		}
+		if isNull {
+			return clause.Eq{Column: column, Value: nil}, nil
+		}
+		return clause.Neq{Column: column, Value: nil}, nil
+	case FilterLike, FilterStartsWith:
+		pattern, ok := condition.Value.(string)
+		if !ok {
+			return nil, invalidFilter("'%s' on '%s' expects a string", condition.Operator, field.DBName)
+		}
+		if condition.Operator == FilterStartsWith {
+			pattern = likeEscaper.Replace(pattern) + "%"
+		}
+		return clause.Expr{SQL: `? LIKE ? ESCAPE '!'`, Vars: []any{column, pattern}}, nil
+	}
+
+	value, err := coerceValue(field, condition.Value)
+	if err != nil {
+		return nil, err
+	}
+	switch condition.Operator {
+	case FilterEq, "":
+		return clause.Eq{Column: column, Value: value}, nil
+	case FilterNe:
+		return clause.Neq{Column: column, Value: value}, nil
+	case FilterGt:
+		return clause.Gt{Column: column, Value: value}, nil
+	case FilterGte:
+		return clause.Gte{Column: column, Value: value}, nil
+	case FilterLt:
+		return clause.Lt{Column: column, Value: value}, nil
+	case FilterLte:
+		return clause.Lte{Column: column, Value: value}, nil
+	default:
+		return nil, invalidFilter("unknown filter operator '%s'", condition.Operator)
+	}
+}
+
+// coerceValue converts string values into the type of the given field, so that
+// filters coming from query strings compare correctly.
+func coerceValue(field *schema.Field, value any) (any, error) {
+	str, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	fieldType := field.FieldType
+	for fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	var result any
+	var err error
+	switch fieldType.Kind() {
+	case reflect.String:
+		return str, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		result, err = strconv.ParseInt(str, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		result, err = strconv.ParseUint(str, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		result, err = strconv.ParseFloat(str, 64)
+	case reflect.Bool:
+		result, err = strconv.ParseBool(str)
+	default:
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			result, err = parseTime(str)
+		} else {
+			return str, nil
+		}
+	}
+	if err != nil {
+		return nil, invalidFilter("invalid value '%s' for '%s'", str, field.DBName)
+	}
+	return result, nil
+}
+
+func parseTime(str string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s'", str)
+}
@@ -0,0 +1,114 @@
+package crud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func create_and_populate_coded_test_db() {
+	create_and_populate_test_db(30)
+	// Contacts Cont-0 .. Cont-29 get codes 0 .. 29 and Cont-0 .. Cont-4 have no email
+	crud_test_db.Exec("UPDATE test_contacts SET code = id - 1")
+	crud_test_db.Exec("UPDATE test_contacts SET email = NULL WHERE code < 5")
+}
+
+func findByCount(t *testing.T, findBy map[string]any) int {
+	filter := Paged(0, 100)
+	filter.FindBy = findBy
+	result, err := contactsService.GetAll(filter)
+	assert.Nil(t, err)
+	count, err := contactsService.CountBy(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, count, result.TotalCount)
+	assert.Len(t, result.List, count)
+	return count
+}
+
+func TestFindByOperators(t *testing.T) {
+	create_and_populate_coded_test_db()
+
+	assert.Equal(t, 1, findByCount(t, map[string]any{"full_name": "Cont-3"}))
+	assert.Equal(t, 1, findByCount(t, map[string]any{"FullName": Eq("Cont-3")}))
+	assert.Equal(t, 29, findByCount(t, map[string]any{"code": Ne(3)}))
+	assert.Equal(t, 9, findByCount(t, map[string]any{"code": Gt(20)}))
+	assert.Equal(t, 10, findByCount(t, map[string]any{"code": Gte(20)}))
+	assert.Equal(t, 20, findByCount(t, map[string]any{"code": Lt(20)}))
+	assert.Equal(t, 21, findByCount(t, map[string]any{"code": Lte(20)}))
+	assert.Equal(t, 3, findByCount(t, map[string]any{"code": In(1, 2, 3)}))
+	assert.Equal(t, 2, findByCount(t, map[string]any{"code": Condition{Operator: FilterIn, Value: []int{1, 2}}}))
+	assert.Equal(t, 27, findByCount(t, map[string]any{"code": NotIn(1, 2, 3)}))
+	assert.Equal(t, 11, findByCount(t, map[string]any{"full_name": Like("Cont-1%")}))
+	assert.Equal(t, 11, findByCount(t, map[string]any{"full_name": StartsWith("Cont-1")}))
+	assert.Equal(t, 0, findByCount(t, map[string]any{"full_name": StartsWith("Cont%")}))
+	assert.Equal(t, 6, findByCount(t, map[string]any{"code": Between(10, 15)}))
+	assert.Equal(t, 5, findByCount(t, map[string]any{"email": IsNull(true)}))
+	assert.Equal(t, 5, findByCount(t, map[string]any{"email": nil}))
+	assert.Equal(t, 25, findByCount(t, map[string]any{"email": IsNull(false)}))
+	assert.Equal(t, 5, findByCount(t, map[string]any{"code": []Condition{Gte(10), Lt(15)}}))
+}
+
+func TestFindByStringValuesAreCoerced(t *testing.T) {
+	create_and_populate_coded_test_db()
+	assert.Equal(t, 9, findByCount(t, map[string]any{"code": Gt("20")}))
+	assert.Equal(t, 2, findByCount(t, map[string]any{"code": In("3", "4")}))
+
+	_, err := contactsService.CountBy(&DataFilter{FindBy: map[string]any{"code": Gt("abc")}})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestFindByGroups(t *testing.T) {
+	create_and_populate_coded_test_db()
+	assert.Equal(t, 4, findByCount(t, map[string]any{
+		FindByOr: []map[string]any{
+			{"code": Lt(2)},
+			{"code": Gt(27)},
+		},
+	}))
+	assert.Equal(t, 3, findByCount(t, map[string]any{
+		"email": IsNull(false),
+		FindByOr: []map[string]any{
+			{"code": Lt(7)},
+			{"full_name": "Cont-29"},
+		},
+	}))
+	assert.Equal(t, 1, findByCount(t, map[string]any{
+		FindByAnd: []map[string]any{
+			{"code": Gte(3)},
+			{FindByOr: []map[string]any{{"full_name": "Cont-3"}, {"full_name": "Cont-1"}}},
+		},
+	}))
+}
+
+func TestFindByWithFindAll(t *testing.T) {
+	create_and_populate_coded_test_db()
+	filter := Paged(0, 10)
+	filter.FindBy = map[string]any{"code": Lt(3)}
+	result, err := contactsService.FindAll(&TestContact{FullName: "Cont-1"}, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.TotalCount)
+
+	filter = Paged(0, 10)
+	filter.FindBy = map[string]any{"code": Gte(25)}
+	result, err = contactsService.FindAllWhere("full_name like ?", "Cont-2%", filter)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, result.TotalCount)
+}
+
+func TestFindByRejectsUnknownColumns(t *testing.T) {
+	create_and_populate_coded_test_db()
+	for _, findBy := range []map[string]any{
+		{"1=1; DROP TABLE test_contacts; --": 1},
+		{"unknown": 1},
+		{FindByOr: []map[string]any{{"code": 1}, {"full_name) or (1=1": 1}}},
+		{FindByOr: "code"},
+		{"code": Condition{Operator: "unknown", Value: 1}},
+		{"code": Condition{Operator: FilterBetween, Value: []any{1}}},
+	} {
+		result, err := contactsService.GetAll(&DataFilter{FindBy: findBy})
+		assert.ErrorIs(t, err, ErrInvalidFilter)
+		assert.Nil(t, result)
+	}
+	count, _ := contactsService.Count()
+	assert.Equal(t, 30, count)
+}
@@ -0,0 +1,164 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	fullTextAlias       = "crud_fts"
+	fullTextRowIdColumn = "crud_fts_rowid"
+	fullTextRankColumn  = "crud_fts_rank"
+)
+
+// BuildFullTextQuery converts a search key into an FTS5 match expression matching all of its terms.
+// Double quoted terms are matched as phrases and terms ending with * as prefixes, e.g. `"John Doe" add*`.
+// Any other FTS5 syntax in the search key is matched literally.
+func BuildFullTextQuery(searchKey string) string {
+	terms := make([]string, 0)
+	addTerm := func(term string, prefix bool) {
+		term = strings.TrimSpace(strings.ReplaceAll(term, `"`, ""))
+		if len(term) == 0 {
+			return
+		}
+		term = `"` + term + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	key := []rune(searchKey)
+	for i := 0; i < len(key); {
+		switch {
+		case unicode.IsSpace(key[i]):
+			i++
+		case key[i] == '"':
+			end := i + 1
+			for end < len(key) && key[end] != '"' {
+				end++
+			}
+			phrase := string(key[i+1 : end])
+			i = end + 1
+			prefix := i < len(key) && key[i] == '*'
+			if prefix {
+				i++
+			}
+			addTerm(phrase, prefix)
+		default:
+			end := i
+			for end < len(key) && !unicode.IsSpace(key[end]) && key[end] != '"' {
+				end++
+			}
+			word := strings.TrimRight(string(key[i:end]), "*")
+			addTerm(word, len(word) < end-i)
+			i = end
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// getFullTextTable returns the name of the FTS5 table indexing the entity.
+func (service *CrudServiceImpl[T, TPublicId]) getFullTextTable() (string, error) {
+	sch, err := service.GetSchema()
+	if err != nil {
+		return "", err
+	}
+	return sch.Table + "_fts", nil
+}
+
+// CreateFullTextIndex creates the SQLite FTS5 table indexing the FullTextColumns of the entity,
+// along with the triggers keeping it in sync with the entity table, and indexes the existing rows.
+// It should be called once the entity table is migrated; calling it again re-indexes all the rows.
+func (service *CrudServiceImpl[T, TPublicId]) CreateFullTextIndex() error {
+	if len(service._options.FullTextColumns) == 0 {
+		return errors.New("full text columns should be provided when using NewCrudService options")
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+	if db.Dialector.Name() != "sqlite" {
+		return fmt.Errorf("full text lookup is not supported by %s", db.Dialector.Name())
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return err
+	}
+	key := sch.PrioritizedPrimaryField
+	if key == nil || key.IndirectFieldType.Kind() < reflect.Int || key.IndirectFieldType.Kind() > reflect.Uint64 {
+		return fmt.Errorf("full text lookup requires an integer primary key for %s", sch.Name)
+	}
+	ftsTable, _ := service.getFullTextTable()
+	quote := db.Statement.Quote
+	columns := make([]string, 0, len(service._options.FullTextColumns))
+	newValues := make([]string, 0, len(service._options.FullTextColumns))
+	oldValues := make([]string, 0, len(service._options.FullTextColumns))
+	for _, name := range service._options.FullTextColumns {
+		field := lookUpColumn(sch, name)
+		if field == nil {
+			return fmt.Errorf("full text column '%s' is not a column of %s", name, sch.Name)
+		}
+		columns = append(columns, quote(field.DBName))
+		newValues = append(newValues, "new."+quote(field.DBName))
+		oldValues = append(oldValues, "old."+quote(field.DBName))
+	}
+	columnList := strings.Join(columns, ", ")
+	insertNew := fmt.Sprintf("INSERT INTO %s(rowid, %s) VALUES (new.%s, %s);",
+		quote(ftsTable), columnList, quote(key.DBName), strings.Join(newValues, ", "))
+	deleteOld := fmt.Sprintf("INSERT INTO %s(%s, rowid, %s) VALUES ('delete', old.%s, %s);",
+		quote(ftsTable), quote(ftsTable), columnList, quote(key.DBName), strings.Join(oldValues, ", "))
+	statements := []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(%s, content=%s, content_rowid=%s)",
+			quote(ftsTable), columnList, quote(sch.Table), quote(key.DBName)),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER INSERT ON %s BEGIN %s END",
+			quote(ftsTable+"_ai"), quote(sch.Table), insertNew),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER DELETE ON %s BEGIN %s END",
+			quote(ftsTable+"_ad"), quote(sch.Table), deleteOld),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER UPDATE ON %s BEGIN %s %s END",
+			quote(ftsTable+"_au"), quote(sch.Table), deleteOld, insertNew),
+		fmt.Sprintf("INSERT INTO %s(%s) VALUES ('rebuild')", quote(ftsTable), quote(ftsTable)),
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to create full text index: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// fullTextLookup returns the page of entities matching the given search key in the full text index,
+// ranked by bm25 unless the filter is sorted or cursor paged.
+func (service *CrudServiceImpl[T, TPublicId]) fullTextLookup(searchKey string, filter *DataFilter) (*PagedList[T], error) {
+	matchQuery := BuildFullTextQuery(searchKey)
+	if len(matchQuery) == 0 {
+		return service.GetAll(filter)
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%s has no primary key", sch.Name)
+	}
+	ftsTable, _ := service.getFullTextTable()
+	db, cancel := service.getDb()
+	defer cancel()
+
+	query := db.Model(new(T)).Joins(
+		fmt.Sprintf("JOIN (SELECT rowid AS %s, bm25(?) AS %s FROM ? WHERE ? MATCH ?) AS %s ON ? = ?",
+			fullTextRowIdColumn, fullTextRankColumn, fullTextAlias),
+		clause.Table{Name: ftsTable}, clause.Table{Name: ftsTable}, clause.Table{Name: ftsTable}, matchQuery,
+		clause.Column{Table: fullTextAlias, Name: fullTextRowIdColumn},
+		clause.Column{Table: sch.Table, Name: sch.PrioritizedPrimaryField.DBName},
+	)
+	if filter == nil || (len(filter.Sort) == 0 && len(filter.SortBy) == 0 && !filter.IsCursorPaged()) {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Table: fullTextAlias, Name: fullTextRankColumn}})
+	}
+	return service.findPaged(query, filter)
+}
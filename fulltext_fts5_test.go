@@ -0,0 +1,101 @@
+package crud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// skipWithoutFts5 skips the test if go-sqlite3 is built without FTS5, i.e. without the sqlite_fts5 tag.
+func skipWithoutFts5(t *testing.T) {
+	if err := crud_test_db.Exec("CREATE VIRTUAL TABLE temp.fts5_probe USING fts5(text)").Error; err != nil {
+		t.Skip("FTS5 is not available, run the tests with -tags sqlite_fts5")
+	}
+	crud_test_db.Exec("DROP TABLE temp.fts5_probe")
+}
+
+func create_full_text_test_service(t *testing.T) ExtendedCrudService[TestContact, string] {
+	create_and_populate_coded_test_db()
+	skipWithoutFts5(t)
+	crud_test_db.Exec("UPDATE test_contacts SET phone = 'John Doe' WHERE code IN (3, 4)")
+	crud_test_db.Exec("UPDATE test_contacts SET full_name = 'Johnny Doe Doe' WHERE code = 7")
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{FullTextColumns: []string{"FullName", "phone"}},
+	)
+	assert.Nil(t, service.CreateFullTextIndex())
+	return service
+}
+
+func TestFullTextLookup(t *testing.T) {
+	service := create_full_text_test_service(t)
+
+	result, err := service.Lookup("doe", Paged(0, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.TotalCount)
+	// The contact mentioning doe twice ranks first
+	assert.Equal(t, []int{7, 3, 4}, codesOf(result.List))
+
+	result, err = service.Lookup(`"john doe"`, Paged(0, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 4}, codesOf(result.List))
+
+	result, err = service.Lookup("joh*", Paged(0, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.TotalCount)
+
+	result, err = service.Lookup("john", Paged(0, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.TotalCount)
+
+	filter := &DataFilter{Sort: "code:desc", Limit: 2, FindBy: map[string]any{"code": Lt(7)}}
+	result, err = service.Lookup("doe", filter)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4, 3}, codesOf(result.List))
+	assert.Equal(t, 2, result.TotalCount)
+
+	result, err = service.Lookup("doe", &DataFilter{Limit: 2, Count: CountWindow, Fields: []string{"code"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.TotalCount)
+	assert.Equal(t, []int{7, 3}, codesOf(result.List))
+
+	result, err = service.Lookup("doe", &DataFilter{Limit: 2, Cursor: true, Sort: "code"})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 4}, codesOf(result.List))
+	result, err = service.Lookup("doe", &DataFilter{Limit: 2, After: result.NextCursor, Sort: "code"})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{7}, codesOf(result.List))
+
+	result, err = service.Lookup("nobody")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.TotalCount)
+	assert.Len(t, result.List, 0)
+}
+
+func TestFullTextIndexSync(t *testing.T) {
+	service := create_full_text_test_service(t)
+
+	created, err := service.Create(&TestContact{FullName: "Jane Roe"})
+	assert.Nil(t, err)
+	result, _ := service.Lookup("roe")
+	assert.Equal(t, 1, result.TotalCount)
+
+	created.FullName = "Jane Smith"
+	_, err = service.Update(created)
+	assert.Nil(t, err)
+	result, _ = service.Lookup("roe")
+	assert.Equal(t, 0, result.TotalCount)
+	result, _ = service.Lookup("smith")
+	assert.Equal(t, 1, result.TotalCount)
+
+	_, err = service.DeleteByPublicId(created.PublicId)
+	assert.Nil(t, err)
+	result, _ = service.Lookup("smith")
+	assert.Equal(t, 0, result.TotalCount)
+
+	// Creating the index again re-indexes the rows
+	assert.Nil(t, service.CreateFullTextIndex())
+	result, _ = service.Lookup("doe")
+	assert.Equal(t, 3, result.TotalCount)
+}
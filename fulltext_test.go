@@ -0,0 +1,30 @@
+package crud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFullTextQuery(t *testing.T) {
+	assert.Equal(t, `"john"`, BuildFullTextQuery("john"))
+	assert.Equal(t, `"john" "doe"`, BuildFullTextQuery("  john   doe "))
+	assert.Equal(t, `"jo"*`, BuildFullTextQuery("jo*"))
+	assert.Equal(t, `"john doe" "add"*`, BuildFullTextQuery(`"john doe" add*`))
+	assert.Equal(t, `"john d"*`, BuildFullTextQuery(`"john d"*`))
+	assert.Equal(t, `"OR" "NEAR(a"`, BuildFullTextQuery(`OR NEAR(a`))
+	assert.Equal(t, `"unterminated phrase"`, BuildFullTextQuery(`"unterminated phrase`))
+	assert.Equal(t, "", BuildFullTextQuery(` "" * `))
+}
+
+func TestCreateFullTextIndexValidation(t *testing.T) {
+	create_and_populate_test_db(3)
+	assert.NotNil(t, contactsService.CreateFullTextIndex())
+
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{FullTextColumns: []string{"full_name", "nickname"}},
+	)
+	assert.ErrorContains(t, service.CreateFullTextIndex(), "nickname")
+}
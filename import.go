@@ -0,0 +1,303 @@
+package crud
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm/schema"
+)
+
+// ImportFormat is the format of the data read by Import.
+type ImportFormat string
+
+const (
+	// ImportCsv is comma separated values, with a header row naming the column, field or JSON name of each value.
+	ImportCsv ImportFormat = "csv"
+	// ImportNdjson is newline delimited JSON, with an entity per line.
+	ImportNdjson ImportFormat = "ndjson"
+	// ImportJson is a JSON array of entities.
+	ImportJson ImportFormat = "json"
+)
+
+const defaultImportBatchSize = 100
+
+type ImportOptions struct {
+	Format ImportFormat
+	// BatchSize is the number of valid rows inserted at once, 100 by default.
+	BatchSize int
+}
+
+// ImportRowError describes why a row was not imported. Rows are numbered by the line they start at,
+// which is also the Index of its field errors.
+type ImportRowError struct {
+	Row     int          `json:"row"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// ImportReport lists the rows an import created entities of and the rows it rejected.
+type ImportReport struct {
+	Accepted []int            `json:"accepted"`
+	Failed   []ImportRowError `json:"failed"`
+}
+
+func newImportRowError(row int, err error) ImportRowError {
+	response := NewErrorResponse(err)
+	fields := make([]FieldError, len(response.Fields))
+	for i, field := range response.Fields {
+		field.Index = row
+		fields[i] = field
+	}
+	return ImportRowError{Row: row, Message: response.Message, Fields: fields}
+}
+
+// importBatch holds the valid rows waiting to be inserted.
+type importBatch[T any] struct {
+	rows     []int
+	entities []T
+}
+
+// readCsv calls fn with each row of the given CSV data, or with the error preventing to read it.
+// The header may not name any of the given managed fields.
+func readCsv[T any](reader io.Reader, sch *schema.Schema, managed []*schema.Field, fn func(row int, entity *T, err error) error) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return NewCrudError(ErrValidation, "invalid CSV header: "+err.Error())
+	}
+	fields := make([]*schema.Field, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		header[i] = name
+		if fields[i] = lookUpColumn(sch, name); fields[i] == nil {
+			return NewCrudError(ErrValidation, fmt.Sprintf("unknown column '%s'", name))
+		}
+		for _, field := range managed {
+			if fields[i] == field {
+				return NewCrudError(ErrValidation, fmt.Sprintf("column '%s' is set by the service and cannot be imported", name))
+			}
+		}
+	}
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err := fn(parseErr.StartLine, nil, NewCrudError(ErrValidation, parseErr.Err.Error())); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		row, _ := csvReader.FieldPos(0)
+		if len(record) != len(header) {
+			err := NewCrudError(ErrValidation, fmt.Sprintf("expected %d values, got %d", len(header), len(record)))
+			if err := fn(row, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+		entity := new(T)
+		fieldErrors := make([]FieldError, 0)
+		for i, value := range record {
+			if len(value) == 0 {
+				continue
+			}
+			coerced, err := coerceValue(fields[i], value)
+			if err == nil {
+				err = fields[i].Set(context.Background(), reflect.ValueOf(entity).Elem(), coerced)
+			}
+			if err != nil {
+				fieldErrors = append(fieldErrors, FieldError{Field: header[i], Message: fmt.Sprintf("invalid value '%s'", value)})
+			}
+		}
+		if len(fieldErrors) > 0 {
+			err = NewValidationError(fieldErrors...)
+		}
+		if err := fn(row, entity, err); err != nil {
+			return err
+		}
+	}
+}
+
+// readNdjson calls fn with each non-empty line of the given newline delimited JSON data,
+// or with the error preventing to read it.
+func readNdjson[T any](reader io.Reader, fn func(row int, entity *T, err error) error) error {
+	bufferedReader := bufio.NewReader(reader)
+	for row := 1; ; row++ {
+		line, err := bufferedReader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			entity := new(T)
+			var rowErr error
+			if jsonErr := json.Unmarshal(trimmed, entity); jsonErr != nil {
+				rowErr = NewCrudError(ErrValidation, "invalid JSON: "+jsonErr.Error())
+			}
+			if err := fn(row, entity, rowErr); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+// readJson calls fn with each entity of the given JSON array, or with the error preventing to read it.
+// The whole array is read first, so that the line of each entity is known.
+func readJson[T any](reader io.Reader, fn func(row int, entity *T, err error) error) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return NewCrudError(ErrValidation, "the JSON data to import should be an array")
+	}
+	for decoder.More() {
+		start := int(decoder.InputOffset())
+		for start < len(data) && (data[start] == ',' || unicode.IsSpace(rune(data[start]))) {
+			start++
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return NewCrudError(ErrValidation, "invalid JSON: "+err.Error())
+		}
+		entity := new(T)
+		var rowErr error
+		if jsonErr := json.Unmarshal(raw, entity); jsonErr != nil {
+			rowErr = NewCrudError(ErrValidation, "invalid JSON: "+jsonErr.Error())
+		}
+		if err := fn(1+bytes.Count(data[:start], []byte("\n")), entity, rowErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getManagedFields returns the fields the service sets rather than the imported data:
+// the primary key, the public id, the version and the soft delete ones.
+func (service *CrudServiceImpl[T, TPublicId]) getManagedFields(sch *schema.Schema) ([]*schema.Field, error) {
+	managed := append([]*schema.Field{}, sch.PrimaryFields...)
+	if field := lookUpColumn(sch, service._options.PublicIdColumnName); field != nil {
+		managed = append(managed, field)
+	}
+	versionField, err := service.getVersionField()
+	if err != nil {
+		return nil, err
+	}
+	if versionField != nil {
+		managed = append(managed, versionField)
+	}
+	if field := softDeleteField(sch); field != nil {
+		managed = append(managed, field)
+	}
+	return managed, nil
+}
+
+// flushImport creates the entities of the given batch, which are validated already. If the batch fails as a whole,
+// its entities are created one by one so that only the failing rows are rejected.
+func (service *CrudServiceImpl[T, TPublicId]) flushImport(batch *importBatch[T], report *ImportReport) error {
+	if len(batch.entities) == 0 {
+		return nil
+	}
+	defer func() {
+		batch.rows = batch.rows[:0]
+		batch.entities = batch.entities[:0]
+	}()
+	if err := service._ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := service.createAll(batch.entities); err == nil {
+		report.Accepted = append(report.Accepted, batch.rows...)
+		return nil
+	}
+	for i := range batch.entities {
+		if _, err := service.createAll(batch.entities[i : i+1]); err != nil {
+			if ctxErr := service._ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			report.Failed = append(report.Failed, newImportRowError(batch.rows[i], err))
+		} else {
+			report.Accepted = append(report.Accepted, batch.rows[i])
+		}
+	}
+	return nil
+}
+
+// Import creates entities from the rows of the given CSV, NDJSON or JSON data. Rows are validated, then inserted
+// in batches; the rows that cannot be read, are invalid or fail to be inserted are reported without stopping the import.
+// It fails only if the data cannot be read at all, e.g. for an unknown CSV column, returning the report so far.
+// The primary key, public id, version and soft delete fields are set by the service: CSV data may not have
+// their columns and their values in JSON data are ignored.
+func (service *CrudServiceImpl[T, TPublicId]) Import(reader io.Reader, options ImportOptions) (*ImportReport, error) {
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	managed, err := service.getManagedFields(sch)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := options.BatchSize
+	if batchSize < 1 {
+		batchSize = defaultImportBatchSize
+	}
+	report := &ImportReport{Accepted: make([]int, 0), Failed: make([]ImportRowError, 0)}
+	batch := &importBatch[T]{}
+	addRow := func(row int, entity *T, err error) error {
+		if err == nil {
+			// JSON rows may set the managed fields too, which are left for the service to set
+			for _, field := range managed {
+				field.ReflectValueOf(service._ctx, reflect.ValueOf(entity).Elem()).Set(reflect.Zero(field.FieldType))
+			}
+			err = service.Validate(OpCreate, []T{*entity})
+		}
+		if err != nil {
+			report.Failed = append(report.Failed, newImportRowError(row, err))
+			return nil
+		}
+		batch.rows = append(batch.rows, row)
+		batch.entities = append(batch.entities, *entity)
+		if len(batch.entities) >= batchSize {
+			return service.flushImport(batch, report)
+		}
+		return nil
+	}
+	switch options.Format {
+	case ImportCsv:
+		err = readCsv(reader, sch, managed, addRow)
+	case ImportNdjson:
+		err = readNdjson(reader, addRow)
+	case ImportJson:
+		err = readJson(reader, addRow)
+	default:
+		return nil, fmt.Errorf("unsupported import format '%s'", options.Format)
+	}
+	if err == nil {
+		err = service.flushImport(batch, report)
+	}
+	return report, err
+}
@@ -0,0 +1,145 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportCsv(t *testing.T) {
+	create_and_populate_test_db(3)
+	crud_test_db.Exec("CREATE UNIQUE INDEX idx_test_contacts_email ON test_contacts(email)")
+
+	data := "\ufeffFullName,code,Email\n" +
+		"Imported-1,1,i1@mail.com\n" +
+		",2,i2@mail.com\n" +
+		"Imported-3,three,i3@mail.com\n" +
+		"Imported-4,4,c_1@gmail.com\n" +
+		"\"Imported\n5\",5,\n" +
+		"Imported-6,6\n" +
+		"Imported-7,-7,not-an-email\n" +
+		"Imported-8,8,i8@mail.com\n"
+	report, err := contactsService.Import(strings.NewReader(data), ImportOptions{Format: ImportCsv, BatchSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 6, 10}, report.Accepted)
+	assert.Len(t, report.Failed, 5)
+
+	assert.Equal(t, 3, report.Failed[0].Row)
+	assert.Equal(t, "FullName", report.Failed[0].Fields[0].Field)
+	assert.Equal(t, 3, report.Failed[0].Fields[0].Index)
+	assert.Equal(t, 4, report.Failed[1].Row)
+	assert.Equal(t, "code", report.Failed[1].Fields[0].Field)
+	// The row conflicting with an existing contact fails alone, the other row of its batch is inserted
+	assert.Equal(t, 5, report.Failed[2].Row)
+	assert.Contains(t, report.Failed[2].Message, "already exists")
+	assert.Equal(t, 8, report.Failed[3].Row)
+	assert.Equal(t, "expected 3 values, got 2", report.Failed[3].Message)
+	assert.Equal(t, 9, report.Failed[4].Row)
+	assert.Len(t, report.Failed[4].Fields, 2)
+
+	count, _ := contactsService.Count()
+	assert.Equal(t, 6, count)
+	imported, err := contactsService.FindOneWhere("full_name = ?", "Imported\n5")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, imported.Code)
+	assert.NotEmpty(t, imported.PublicId)
+}
+
+func TestImportNdjson(t *testing.T) {
+	create_and_populate_test_db(0)
+
+	data := `{"FullName": "Imported-1", "Code": 1}` + "\n\n" +
+		`{"FullName": "Imported-2", "Code": "two"}` + "\n" +
+		`{"Code": 3}` + "\n" +
+		`{"FullName": "Imported-4"}`
+	report, err := contactsService.Import(strings.NewReader(data), ImportOptions{Format: ImportNdjson})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 5}, report.Accepted)
+	assert.Len(t, report.Failed, 2)
+	assert.Equal(t, 3, report.Failed[0].Row)
+	assert.Contains(t, report.Failed[0].Message, "invalid JSON")
+	assert.Equal(t, 4, report.Failed[1].Row)
+
+	count, _ := contactsService.Count()
+	assert.Equal(t, 2, count)
+}
+
+func TestImportErrors(t *testing.T) {
+	create_and_populate_test_db(0)
+
+	_, err := contactsService.Import(strings.NewReader("full_name,nickname\na,b\n"), ImportOptions{Format: ImportCsv})
+	assert.True(t, errors.Is(err, ErrValidation))
+	assert.Contains(t, err.Error(), "nickname")
+
+	_, err = contactsService.Import(strings.NewReader(""), ImportOptions{Format: "xlsx"})
+	assert.NotNil(t, err)
+
+	report, err := contactsService.Import(strings.NewReader(""), ImportOptions{Format: ImportCsv})
+	assert.Nil(t, err)
+	assert.Empty(t, report.Accepted)
+	assert.Empty(t, report.Failed)
+}
+
+func TestImportJson(t *testing.T) {
+	create_and_populate_test_db(0)
+
+	data := "[\n" +
+		`  {"FullName": "Imported-1", "Code": 1},` + "\n" +
+		`  {"FullName": "Imported-2", "Code": "two"},` + "\n" +
+		"  {\n    \"Code\": 3\n  },\n" +
+		`  {"FullName": "Imported-4"}` + "\n]"
+	report, err := contactsService.Import(strings.NewReader(data), ImportOptions{Format: ImportJson})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 7}, report.Accepted)
+	assert.Len(t, report.Failed, 2)
+	assert.Equal(t, 3, report.Failed[0].Row)
+	assert.Contains(t, report.Failed[0].Message, "invalid JSON")
+	assert.Equal(t, 4, report.Failed[1].Row)
+	assert.Equal(t, 4, report.Failed[1].Fields[0].Index)
+
+	_, err = contactsService.Import(strings.NewReader(`{"FullName": "Imported-1"}`), ImportOptions{Format: ImportJson})
+	assert.True(t, errors.Is(err, ErrValidation))
+}
+
+func TestImportValidatesOnce(t *testing.T) {
+	create_and_populate_test_db(0)
+	validated := 0
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{Validators: []ValidateFunc[TestContact]{
+			func(ctx context.Context, op Operation, c *TestContact) error {
+				validated++
+				return nil
+			},
+		}})
+
+	report, err := service.Import(strings.NewReader("full_name\nImported-1\nImported-2\nImported-3\n"), ImportOptions{Format: ImportCsv, BatchSize: 2})
+	assert.Nil(t, err)
+	assert.Len(t, report.Accepted, 3)
+	assert.Equal(t, 3, validated)
+}
+
+func TestImportManagedColumns(t *testing.T) {
+	service := create_version_test_service("version")
+	for _, column := range []string{"id", "PublicId", "version"} {
+		_, err := service.Import(strings.NewReader(column+",title\n7,Imported\n"), ImportOptions{Format: ImportCsv})
+		assert.ErrorIs(t, err, ErrValidation, column)
+		assert.Contains(t, err.Error(), column)
+	}
+	count, _ := service.Count()
+	assert.Equal(t, 0, count)
+
+	data := `{"Id": 7, "PublicId": "imported", "Title": "Imported", "Version": 5}`
+	report, err := service.Import(strings.NewReader(data), ImportOptions{Format: ImportNdjson})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, report.Accepted)
+	imported, err := service.FindOneWhere("title = ?", "Imported")
+	assert.Nil(t, err)
+	assert.NotEqual(t, 7, imported.Id)
+	assert.NotEqual(t, "imported", imported.PublicId)
+	assert.Equal(t, 1, imported.Version)
+}
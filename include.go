@@ -0,0 +1,83 @@
+package crud
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// getRelationship returns the top-level relationship the given preload path starts with.
+func getRelationship(sch *schema.Schema, preload string) *schema.Relationship {
+	return sch.Relationships.Relations[strings.SplitN(preload, ".", 2)[0]]
+}
+
+// resolveIncludes returns the allowed preloads the given (possibly comma separated) include names refer to.
+// An include refers to an allowed preload by its name, ignoring case, or by the JSON name of its relation.
+func resolveIncludes(sch *schema.Schema, names []string, allowedPreloads []string) ([]string, error) {
+	names = splitFields(names)
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		preload := ""
+		for _, allowed := range allowedPreloads {
+			if strings.EqualFold(name, allowed) {
+				preload = allowed
+				break
+			}
+			if relationship := getRelationship(sch, allowed); relationship != nil && !strings.Contains(allowed, ".") &&
+				jsonName(relationship.Field) == name {
+				preload = allowed
+				break
+			}
+		}
+		if len(preload) == 0 {
+			return nil, invalidFilter("cannot include '%s'", name)
+		}
+		result = append(result, preload)
+	}
+	return result, nil
+}
+
+// getIncludes returns the preloads requested by the filter.
+func (service *CrudServiceImpl[T, TPublicId]) getIncludes(filter *DataFilter) ([]string, error) {
+	if len(filter.Include) == 0 {
+		return nil, nil
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	return resolveIncludes(sch, filter.Include, service._options.AllowedPreloads)
+}
+
+// preloadKeys returns the columns of the entity the given preloads are joined by,
+// which should be selected along with the fields of a projection.
+func (service *CrudServiceImpl[T, TPublicId]) preloadKeys(preloads []string) ([]string, error) {
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0)
+	for _, preload := range preloads {
+		relationship := getRelationship(sch, preload)
+		if relationship == nil {
+			continue
+		}
+		for _, reference := range relationship.References {
+			if reference.PrimaryKey != nil && reference.PrimaryKey.Schema == sch {
+				result = append(result, reference.PrimaryKey.DBName)
+			}
+			if reference.ForeignKey != nil && reference.ForeignKey.Schema == sch {
+				result = append(result, reference.ForeignKey.DBName)
+			}
+		}
+	}
+	return result, nil
+}
+
+func applyPreloads(query *gorm.DB, preloads []string) *gorm.DB {
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	return query
+}
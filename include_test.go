@@ -0,0 +1,49 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func create_and_populate_associated_test_db() {
+	create_and_populate_test_db(5)
+	contact, _ := contactsService.FindOneByPublicId(crud_test_public_ids[1])
+	contact.Tags = []TestTag{{PublicId: "t1", Name: "family"}, {PublicId: "t2", Name: "work"}}
+	contact.Addresses = []TestAddress{{City: "Addis Ababa"}}
+	crud_test_db.Save(contact)
+}
+
+func TestInclude(t *testing.T) {
+	create_and_populate_associated_test_db()
+
+	result, err := contactsService.GetAll(&DataFilter{Limit: 2, Include: []string{"tags,Addresses"}})
+	assert.Nil(t, err)
+	assert.Len(t, result.List[1].Tags, 2)
+	assert.Equal(t, "Addis Ababa", result.List[1].Addresses[0].City)
+	assert.Len(t, result.List[0].Tags, 0)
+
+	result, err = contactsService.GetAll(&DataFilter{Limit: 2, Include: []string{"Tags"}, Fields: []string{"full_name"},
+		Cursor: true, Sort: "id"})
+	assert.Nil(t, err)
+	assert.Len(t, result.List[1].Tags, 2)
+
+	result, err = contactsService.GetAll(&DataFilter{Limit: 2})
+	assert.Nil(t, err)
+	assert.Nil(t, result.List[1].Tags)
+
+	contact, err := contactsService.FindOneByPublicIdWith(crud_test_public_ids[1], &DataFilter{Include: []string{"addresses"}})
+	assert.Nil(t, err)
+	assert.Len(t, contact.Addresses, 1)
+	assert.Nil(t, contact.Tags)
+}
+
+func TestIncludeRejectsUnknownRelations(t *testing.T) {
+	create_and_populate_associated_test_db()
+
+	_, err := contactsService.GetAll(&DataFilter{Include: []string{"friends"}})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	_, err = tagsService.GetAll(&DataFilter{Include: []string{"contacts"}})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
@@ -0,0 +1,61 @@
+package crud
+
+import "context"
+
+// iterationFilter returns a copy of the given filter reading batches of the given size in keyset order.
+func (service *CrudServiceImpl[T, TPublicId]) iterationFilter(filter *DataFilter, batchSize int) *DataFilter {
+	result := &DataFilter{}
+	if filter != nil {
+		*result = *filter
+		result.SortBy = append([]SortInfo{}, filter.SortBy...)
+	}
+	if batchSize < 1 {
+		batchSize = service._options.DefaultPageSize
+	}
+	result.Limit = batchSize
+	result.Page = 0
+	result.Offset = 0
+	result.Cursor = true
+	result.Before = ""
+	result.Count = CountSkip
+	return result
+}
+
+// nextBatch reads the batch of entities following the given one, the first batch if it is nil.
+// The returned batch is nil once all the entities are read.
+func (service *CrudServiceImpl[T, TPublicId]) nextBatch(ctx context.Context, filter *DataFilter, previous *PagedList[T]) (*PagedList[T], error) {
+	if previous != nil {
+		if !previous.HasNext {
+			return nil, nil
+		}
+		filter.After = previous.NextCursor
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return service.WithContext(ctx).GetAll(filter)
+}
+
+// Iterate calls fn with the successive batches of the entities matching the filter, in keyset order,
+// until all of them are read or fn fails. The FindBy, sort, fields, include and after cursor of the filter apply;
+// the batches hold batchSize entities, DefaultPageSize by default, so memory use does not grow with the table.
+// It stops with the error of fn or the context.
+func (service *CrudServiceImpl[T, TPublicId]) Iterate(ctx context.Context, filter *DataFilter, batchSize int, fn func(batch []T) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	batchFilter := service.iterationFilter(filter, batchSize)
+	var batch *PagedList[T]
+	var err error
+	for {
+		if batch, err = service.nextBatch(ctx, batchFilter, batch); err != nil {
+			return err
+		}
+		if batch == nil || len(batch.List) == 0 {
+			return nil
+		}
+		if err := fn(batch.List); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build go1.23
+
+package crud
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator over the entities matching the filter, reading them in batches of batchSize as Iterate does.
+// A failure is yielded along with the zero entity and ends the iteration.
+func (service *CrudServiceImpl[T, TPublicId]) All(ctx context.Context, filter *DataFilter, batchSize int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		batchFilter := service.iterationFilter(filter, batchSize)
+		var batch *PagedList[T]
+		var err error
+		for {
+			if batch, err = service.nextBatch(ctx, batchFilter, batch); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			if batch == nil {
+				return
+			}
+			for _, entity := range batch.List {
+				if !yield(entity, nil) {
+					return
+				}
+			}
+		}
+	}
+}
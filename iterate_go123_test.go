@@ -0,0 +1,41 @@
+//go:build go1.23
+
+package crud
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAll(t *testing.T) {
+	create_and_populate_coded_test_db()
+	service := contactsService.(*CrudServiceImpl[TestContact, string])
+
+	codes := make([]int, 0)
+	for contact, err := range service.All(context.Background(), &DataFilter{Sort: "code:desc"}, 4) {
+		assert.Nil(t, err)
+		codes = append(codes, contact.Code)
+	}
+	assert.Len(t, codes, 30)
+	assert.Equal(t, 29, codes[0])
+	assert.Equal(t, 0, codes[29])
+
+	codes = codes[:0]
+	for contact := range service.All(context.Background(), &DataFilter{Sort: "code"}, 4) {
+		codes = append(codes, contact.Code)
+		if len(codes) == 6 {
+			break
+		}
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, codes)
+
+	failures := 0
+	for _, err := range service.All(context.Background(), &DataFilter{Sort: "nickname"}, 4) {
+		assert.True(t, errors.Is(err, ErrInvalidFilter))
+		failures++
+	}
+	assert.Equal(t, 1, failures)
+}
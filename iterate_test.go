@@ -0,0 +1,77 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterate(t *testing.T) {
+	create_and_populate_coded_test_db()
+	service := contactsService.(*CrudServiceImpl[TestContact, string])
+
+	sizes := make([]int, 0)
+	codes := make([]int, 0)
+	err := service.Iterate(context.Background(), nil, 7, func(batch []TestContact) error {
+		sizes = append(sizes, len(batch))
+		codes = append(codes, codesOf(batch)...)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{7, 7, 7, 7, 2}, sizes)
+	// Without a sort, entities are read in public id order
+	sort.Ints(codes)
+	for i, code := range codes {
+		assert.Equal(t, i, code)
+	}
+
+	filter := &DataFilter{Sort: "code:desc", FindBy: map[string]any{"code": Gte(10)}, Limit: 3, Page: 2}
+	codes = codes[:0]
+	err = service.Iterate(context.Background(), filter, 6, func(batch []TestContact) error {
+		codes = append(codes, codesOf(batch)...)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, codes, 20)
+	assert.Equal(t, 29, codes[0])
+	assert.Equal(t, 10, codes[19])
+	assert.Equal(t, "code:desc", filter.Sort)
+	assert.Equal(t, 3, filter.Limit)
+
+	// Iterating nothing does not call fn
+	err = service.Iterate(context.Background(), &DataFilter{FindBy: map[string]any{"code": Gt(100)}}, 5, func(batch []TestContact) error {
+		t.Fail()
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestIterateStops(t *testing.T) {
+	create_and_populate_coded_test_db()
+	service := contactsService.(*CrudServiceImpl[TestContact, string])
+
+	stop := errors.New("stop")
+	calls := 0
+	err := service.Iterate(context.Background(), nil, 10, func(batch []TestContact) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = service.Iterate(ctx, nil, 10, func(batch []TestContact) error {
+		calls++
+		cancel()
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, calls)
+
+	err = service.Iterate(context.Background(), &DataFilter{Sort: "nickname"}, 10, func(batch []TestContact) error { return nil })
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
@@ -0,0 +1,90 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// LookupField is a column searched by a lookup profile, by its column, field or JSON name.
+// A token equal to the column value scores 3 times the Weight, a prefix of it twice and any other part of it once.
+// The Weight is 1 by default.
+type LookupField struct {
+	Column string
+	Weight int
+}
+
+// LookupProfile is a named way of looking entities up: the search key is split into tokens
+// and every token should be found in some of the Fields. The results are sorted by their score.
+type LookupProfile struct {
+	Fields []LookupField
+}
+
+// lookupTokens splits the given search key into lower cased tokens.
+func lookupTokens(searchKey string) []string {
+	return strings.Fields(strings.ToLower(searchKey))
+}
+
+// LookupWithProfile returns the page of entities matching every token of the given search key in the fields
+// of the given lookup profile, sorted by score unless the filter is sorted or cursor paged.
+// Unknown profiles fail with ErrInvalidFilter and profiles with unknown columns with ErrInvalidOptions.
+func (service *CrudServiceImpl[T, TPublicId]) LookupWithProfile(searchKey string, profileName string, filterParam ...*DataFilter) (*PagedList[T], error) {
+	var filter *DataFilter
+	if len(filterParam) > 0 {
+		filter = filterParam[0]
+	}
+	profile, ok := service._options.LookupProfiles[profileName]
+	if !ok {
+		return nil, invalidFilter("unknown lookup profile '%s'", profileName)
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]clause.Expr, len(profile.Fields))
+	weights := make([]int, len(profile.Fields))
+	for i, lookupField := range profile.Fields {
+		field := lookUpColumn(sch, lookupField.Column)
+		if field == nil {
+			return nil, NewCrudError(ErrInvalidOptions,
+				fmt.Sprintf("lookup column '%s' of profile '%s' is not a column of %s", lookupField.Column, profileName, sch.Name))
+		}
+		columns[i] = clause.Expr{SQL: "LOWER(?)", Vars: []any{clause.Column{Table: sch.Table, Name: field.DBName}}}
+		weights[i] = lookupField.Weight
+		if weights[i] == 0 {
+			weights[i] = 1
+		}
+	}
+	tokens := lookupTokens(searchKey)
+	if len(tokens) == 0 || len(columns) == 0 {
+		return service.GetAll(filter)
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+
+	query := db.Model(new(T))
+	scores := make([]string, 0, len(tokens)*len(columns))
+	scoreVars := make([]any, 0, 4*len(tokens)*len(columns))
+	for _, token := range tokens {
+		escaped := likeEscaper.Replace(token)
+		matches := make([]clause.Expression, len(columns))
+		for i, column := range columns {
+			matches[i] = clause.Expr{SQL: `? LIKE ? ESCAPE '!'`, Vars: []any{column, "%" + escaped + "%"}}
+			scores = append(scores, fmt.Sprintf(`CASE WHEN ? = ? THEN %d WHEN ? LIKE ? ESCAPE '!' THEN %d WHEN ? LIKE ? ESCAPE '!' THEN %d ELSE 0 END`,
+				3*weights[i], 2*weights[i], weights[i]))
+			scoreVars = append(scoreVars, column, token, column, escaped+"%", column, "%"+escaped+"%")
+		}
+		query = query.Where(clause.Or(matches...))
+	}
+	if filter == nil || (len(filter.Sort) == 0 && len(filter.SortBy) == 0 && !filter.IsCursorPaged()) {
+		orderBy := "(" + strings.Join(scores, " + ") + ") DESC"
+		if sch.PrioritizedPrimaryField != nil {
+			orderBy += ", ?"
+			scoreVars = append(scoreVars, clause.Column{Table: sch.Table, Name: sch.PrioritizedPrimaryField.DBName})
+		}
+		// An order by expression replaces any order by column, so the primary key tiebreaker is part of it
+		query = query.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: orderBy, Vars: scoreVars, WithoutParentheses: true}})
+	}
+	return service.findPaged(query, filter)
+}
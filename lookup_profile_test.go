@@ -0,0 +1,94 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func create_lookup_profile_test_service() ExtendedCrudService[TestContact, string] {
+	create_and_populate_test_db(10)
+	crud_test_db.Exec("UPDATE test_contacts SET full_name = 'John Smith', email = 'js@mail.com' WHERE id = 1")
+	crud_test_db.Exec("UPDATE test_contacts SET full_name = 'Johnny Smithers', email = 'john@smith.com' WHERE id = 2")
+	crud_test_db.Exec("UPDATE test_contacts SET full_name = 'Jane Smith', email = 'john.smith@mail.com' WHERE id = 3")
+	crud_test_db.Exec("UPDATE test_contacts SET full_name = 'John', email = '100%@mail.com' WHERE id = 4")
+	crud_test_db.Exec("UPDATE test_contacts SET full_name = 'Wow! Inc' WHERE id = 5")
+	return NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{
+			LookupQuery: "full_name like ?",
+			LookupProfiles: map[string]LookupProfile{
+				"name":    {Fields: []LookupField{{Column: "FullName", Weight: 2}, {Column: "email"}}},
+				"invalid": {Fields: []LookupField{{Column: "nickname"}}},
+			},
+		},
+	)
+}
+
+func fullNamesOf(list []TestContact) []string {
+	result := make([]string, len(list))
+	for i, c := range list {
+		result[i] = c.FullName
+	}
+	return result
+}
+
+func TestProfileLookup(t *testing.T) {
+	service := create_lookup_profile_test_service()
+
+	// Every token should match, scored by field weight: exact > prefix > contains
+	result, err := service.LookupWithProfile("john SMITH", "name")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.TotalCount)
+	assert.Equal(t, []string{"Johnny Smithers", "John Smith", "Jane Smith"}, fullNamesOf(result.List))
+
+	result, err = service.LookupWithProfile("john", "name", Paged(0, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Johnny Smithers", "John", "John Smith", "Jane Smith"}, fullNamesOf(result.List))
+
+	result, err = service.LookupWithProfile("john", "name", &DataFilter{Sort: "full_name:desc", Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, result.TotalCount)
+	assert.Equal(t, []string{"Johnny Smithers", "John Smith"}, fullNamesOf(result.List))
+
+	result, err = service.LookupWithProfile("john", "name", &DataFilter{FindBy: map[string]any{"email": Like("%mail.com")}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"John", "John Smith", "Jane Smith"}, fullNamesOf(result.List))
+
+	// LIKE wildcards in the search key are matched literally
+	result, err = service.LookupWithProfile("100%", "name")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"John"}, fullNamesOf(result.List))
+	result, err = service.LookupWithProfile("j_", "name")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.TotalCount)
+	// and so is the escape character
+	result, err = service.LookupWithProfile("wow!", "name")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Wow! Inc"}, fullNamesOf(result.List))
+	result, err = service.LookupWithProfile("w!%", "name")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.TotalCount)
+
+	result, err = service.LookupWithProfile("  ", "name", Paged(0, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, result.TotalCount)
+
+	// Without a profile, the lookup query is used
+	result, err = service.Lookup("john smith")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"John Smith"}, fullNamesOf(result.List))
+}
+
+func TestProfileLookupErrors(t *testing.T) {
+	service := create_lookup_profile_test_service()
+
+	_, err := service.LookupWithProfile("john", "unknown")
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+
+	_, err = service.LookupWithProfile("john", "invalid")
+	assert.True(t, errors.Is(err, ErrInvalidOptions))
+	assert.ErrorContains(t, err, "nickname")
+}
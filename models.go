@@ -1,8 +1,10 @@
 package crud
 
 import (
+	"fmt"
 	"math"
 	"strings"
+	"unicode"
 )
 
 type SortInfo struct {
@@ -17,6 +19,23 @@ type DataFilter struct {
 	Sort   string `form:"sort"`
 	SortBy []SortInfo
 	FindBy map[string]any
+	// After and Before are cursors returned in PagedList.NextCursor and PrevCursor.
+	// Giving either of them (or setting Cursor for the first page) pages by keyset instead of offset.
+	After  string `form:"after"`
+	Before string `form:"before"`
+	Cursor bool   `form:"cursor"`
+	// Count overrides the count strategy of the service for this query.
+	Count CountStrategy `form:"count"`
+	// Fields restricts the selected columns to the given ones (or their JSON names), always including the public id.
+	// Items may hold several comma separated fields.
+	Fields []string `form:"fields"`
+	// Include lists the associations to load along with the entities, out of the allowed preloads of the service.
+	Include []string `form:"include"`
+}
+
+// IsCursorPaged tells whether the filter pages by keyset instead of offset.
+func (filter *DataFilter) IsCursorPaged() bool {
+	return filter.Cursor || len(filter.After) > 0 || len(filter.Before) > 0
 }
 
 func Paged(page int, limit int) *DataFilter {
@@ -36,6 +55,11 @@ type PagedList[T any] struct {
 	HasPrevious bool `json:"hasPrevious"`
 	TotalPages  int  `json:"totalPages"`
 	Skip        int  `json:"skip"`
+	// NextCursor and PrevCursor are set for cursor-paged lists having a next or previous page.
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	// CountExact tells whether TotalCount and TotalPages are exact, see CountStrategy.
+	CountExact bool `json:"countExact"`
 }
 
 func NewPagedList[T any](list []T, totalCount int, filter *DataFilter) *PagedList[T] {
@@ -49,9 +73,36 @@ func NewPagedList[T any](list []T, totalCount int, filter *DataFilter) *PagedLis
 		HasPrevious: filter.Page != 0,
 		TotalPages:  totalPages,
 		Skip:        filter.Page * filter.Limit,
+		CountExact:  true,
 	}
 }
 
+// ParseSort parses sort specifications such as "name:asc,age:desc".
+// The direction of a column is optional and ascending by default.
+func ParseSort(sort string) ([]SortInfo, error) {
+	result := make([]SortInfo, 0)
+	for _, s := range strings.Split(sort, ",") {
+		sortSpec := strings.Split(strings.TrimSpace(s), ":")
+		sortInfo := SortInfo{Column: strings.TrimSpace(sortSpec[0])}
+		if len(sortInfo.Column) == 0 || len(sortSpec) > 2 {
+			return nil, NewCrudError(ErrInvalidFilter, fmt.Sprintf("invalid sort '%s'", s))
+		}
+		if len(sortSpec) > 1 {
+			switch strings.ToLower(strings.TrimSpace(sortSpec[1])) {
+			case "asc":
+			case "desc":
+				sortInfo.Desc = true
+			default:
+				return nil, NewCrudError(ErrInvalidFilter, fmt.Sprintf("invalid sort direction '%s'", sortSpec[1]))
+			}
+		}
+		result = append(result, sortInfo)
+	}
+	return result, nil
+}
+
+// NormalizeFilter applies the defaults to the given filter and moves its Sort specification into SortBy.
+// Invalid sort specifications are ignored, use ParseSort to validate them.
 func NormalizeFilter(filter *DataFilter, defaultPageSize int) *DataFilter {
 	if filter == nil {
 		filter = &DataFilter{Page: 0, Limit: defaultPageSize}
@@ -61,16 +112,11 @@ func NormalizeFilter(filter *DataFilter, defaultPageSize int) *DataFilter {
 		}
 	}
 	if len(filter.Sort) > 0 {
-		sortInfos := strings.Split(filter.Sort, ",")
-		for _, s := range sortInfos {
-			sortSpec := strings.Split(s, ":")
-			sortInfo := SortInfo{Column: sortSpec[0]}
-			if len(sortSpec) > 1 {
-				sortInfo.Desc = strings.ToLower(sortSpec[1]) == "desc"
-			}
-
-			filter.SortBy = append(filter.SortBy, sortInfo)
+		sortInfos, err := ParseSort(filter.Sort)
+		if err == nil {
+			filter.SortBy = append(filter.SortBy, sortInfos...)
 		}
+		filter.Sort = ""
 	}
 	if filter.Offset > 0 {
 		filter.Page = filter.Offset / filter.Limit
@@ -78,9 +124,32 @@ func NormalizeFilter(filter *DataFilter, defaultPageSize int) *DataFilter {
 	return filter
 }
 
+// isPlainIdentifier tells whether the given column is made of letters, digits and underscores only,
+// optionally qualified by a table name.
+func isPlainIdentifier(column string) bool {
+	for _, part := range strings.Split(column, ".") {
+		if len(part) == 0 {
+			return false
+		}
+		for _, r := range part {
+			if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GetOrderByQuery returns the ORDER BY clause of the SortBy of the given filter.
+// Columns that are not plain identifiers are left out, so that they cannot inject SQL.
+//
+// Deprecated: the queries of the CRUD service sort by the columns of the entity only, see SortableColumns.
 func GetOrderByQuery(filter *DataFilter) string {
 	result := make([]string, 0)
 	for _, sort := range filter.SortBy {
+		if !isPlainIdentifier(sort.Column) {
+			continue
+		}
 		item := sort.Column
 		if sort.Desc {
 			item += " desc"
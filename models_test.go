@@ -45,4 +45,31 @@ func TestGetOrderByQuery(t *testing.T) {
 	}))
 
 	assert.Equal(t, "col1,col2 desc,col3,col4 desc", q)
+
+	q = GetOrderByQuery(&DataFilter{SortBy: []SortInfo{
+		{Column: "contacts.full_name"},
+		{Column: "(CASE WHEN 1=1 THEN name END)"},
+		{Column: "name; DROP TABLE contacts"},
+		{Column: "code", Desc: true},
+	}})
+	assert.Equal(t, "contacts.full_name,code desc", q)
+}
+
+func TestParseSort(t *testing.T) {
+	sortInfos, err := ParseSort("name, age:DESC ,salary:asc")
+	assert.Nil(t, err)
+	assert.Equal(t, []SortInfo{{Column: "name"}, {Column: "age", Desc: true}, {Column: "salary"}}, sortInfos)
+
+	for _, sort := range []string{"name:up", "name:desc:asc", ",name", "name:"} {
+		_, err := ParseSort(sort)
+		assert.ErrorIs(t, err, ErrInvalidFilter, sort)
+	}
+}
+
+func TestNormalizeFilterIsIdempotent(t *testing.T) {
+	filter := Paged(0, 10)
+	filter.Sort = "name:asc"
+	NormalizeFilter(filter, 20)
+	NormalizeFilter(filter, 20)
+	assert.Len(t, filter.SortBy, 1)
 }
@@ -0,0 +1,116 @@
+package crud
+
+import (
+	"encoding/json"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// splitFields splits the comma separated items of the given field list.
+func splitFields(fields []string) []string {
+	result := make([]string, 0, len(fields))
+	for _, item := range fields {
+		for _, name := range strings.Split(item, ",") {
+			if name = strings.TrimSpace(name); len(name) > 0 {
+				result = append(result, name)
+			}
+		}
+	}
+	return result
+}
+
+// resolveFields looks up the fields of the given (possibly comma separated) names followed by the public id field,
+// rejecting the ones that are not columns of the model. It returns nil when no names are given.
+func resolveFields(sch *schema.Schema, names []string, publicIdColumnName string) ([]*schema.Field, error) {
+	names = splitFields(names)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	result := make([]*schema.Field, 0, len(names)+1)
+	seen := make(map[string]bool)
+	for _, name := range append(names, publicIdColumnName) {
+		field := lookUpColumn(sch, name)
+		if field == nil {
+			return nil, invalidFilter("unknown field '%s'", name)
+		}
+		if !seen[field.DBName] {
+			seen[field.DBName] = true
+			result = append(result, field)
+		}
+	}
+	return result, nil
+}
+
+// getSelectedFields returns the fields the filter restricts the query to, or nil to select all of them.
+func (service *CrudServiceImpl[T, TPublicId]) getSelectedFields(filter *DataFilter) ([]*schema.Field, error) {
+	if len(filter.Fields) == 0 {
+		return nil, nil
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	return resolveFields(sch, filter.Fields, service._options.PublicIdColumnName)
+}
+
+// columnNames returns the column names of the given fields along with the extra ones, without duplicates.
+func columnNames(fields []*schema.Field, extra ...string) []string {
+	names := make([]string, 0, len(fields)+len(extra))
+	for _, field := range fields {
+		names = append(names, field.DBName)
+	}
+	result := make([]string, 0, len(names)+len(extra))
+	seen := make(map[string]bool)
+	for _, name := range append(names, extra...) {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// projectedKeys returns the JSON names of the fields selected by the filter and of the associations it includes.
+func projectedKeys(sch *schema.Schema, filter *DataFilter, publicIdColumnName string, allowedPreloads []string) ([]string, error) {
+	fields, err := resolveFields(sch, filter.Fields, publicIdColumnName)
+	if err != nil {
+		return nil, err
+	}
+	preloads, err := resolveIncludes(sch, filter.Include, allowedPreloads)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(fields)+len(preloads))
+	for _, field := range fields {
+		result = append(result, jsonName(field))
+	}
+	for _, preload := range preloads {
+		if relationship := getRelationship(sch, preload); relationship != nil {
+			result = append(result, jsonName(relationship.Field))
+		}
+	}
+	return result, nil
+}
+
+// projectFields returns the JSON objects of the given entities holding only the given keys.
+func projectFields[T any](keys []string, entities []T) ([]map[string]json.RawMessage, error) {
+	result := make([]map[string]json.RawMessage, len(entities))
+	for i := range entities {
+		content, err := json.Marshal(&entities[i])
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(content, &all); err != nil {
+			return nil, err
+		}
+		result[i] = make(map[string]json.RawMessage, len(keys))
+		for _, key := range keys {
+			if value, ok := all[key]; ok && len(key) > 0 {
+				result[i][key] = value
+			}
+		}
+	}
+	return result, nil
+}
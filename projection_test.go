@@ -0,0 +1,40 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectedFields(t *testing.T) {
+	create_and_populate_coded_test_db()
+
+	for _, strategy := range []CountStrategy{CountExact, CountWindow} {
+		result, err := contactsService.GetAll(&DataFilter{Limit: 3, Sort: "code", Fields: []string{"full_name,Code"}, Count: strategy})
+		assert.Nil(t, err, strategy)
+		assert.Equal(t, 30, result.TotalCount, strategy)
+		c := result.List[2]
+		assert.Equal(t, "Cont-2", c.FullName, strategy)
+		assert.Equal(t, 2, c.Code, strategy)
+		assert.NotEmpty(t, c.PublicId, strategy)
+		assert.Empty(t, c.Email, strategy)
+		assert.Zero(t, c.Id, strategy)
+	}
+
+	first, err := contactsService.GetAll(&DataFilter{Limit: 3, Sort: "code", Cursor: true, Fields: []string{"email"}})
+	assert.Nil(t, err)
+	assert.Empty(t, first.List[0].FullName)
+	next, err := contactsService.GetAll(&DataFilter{Limit: 3, Sort: "code", After: first.NextCursor, Fields: []string{"email"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "c_5@gmail.com", next.List[2].Email)
+}
+
+func TestUnknownSelectedFields(t *testing.T) {
+	create_and_populate_test_db(3)
+
+	for _, fields := range []string{"nickname", "full_name,(select 1)"} {
+		_, err := contactsService.GetAll(&DataFilter{Fields: []string{fields}})
+		assert.True(t, errors.Is(err, ErrInvalidFilter), fields)
+	}
+}
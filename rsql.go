@@ -0,0 +1,301 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RsqlSyntaxError reports an invalid RSQL expression. It matches ErrInvalidFilter with errors.Is.
+type RsqlSyntaxError struct {
+	// Position is the byte offset of the offending part of the expression.
+	Position int
+	Message  string
+}
+
+func (e *RsqlSyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+func (e *RsqlSyntaxError) Is(target error) bool {
+	return target == ErrInvalidFilter
+}
+
+var rsqlOperators = map[string]FilterOperator{
+	"==":        FilterEq,
+	"!=":        FilterNe,
+	"=gt=":      FilterGt,
+	">":         FilterGt,
+	"=ge=":      FilterGte,
+	">=":        FilterGte,
+	"=lt=":      FilterLt,
+	"<":         FilterLt,
+	"=le=":      FilterLte,
+	"<=":        FilterLte,
+	"=in=":      FilterIn,
+	"=out=":     FilterNotIn,
+	"=like=":    FilterLike,
+	"=between=": FilterBetween,
+	"=isnull=":  FilterIsNull,
+}
+
+const rsqlReserved = `"'();,=!~<> `
+
+type rsqlParser struct {
+	expression string
+	position   int
+	allowed    map[string]bool
+}
+
+// ParseRsql parses an RSQL/FIQL expression such as `full_name=like=J*;(code=gt=5,email=out=(x,y))`
+// into FindBy conditions. ';' combines comparisons with AND, ',' with OR and parentheses group them.
+// The supported operators are ==, !=, =gt= (>), =ge= (>=), =lt= (<), =le= (<=), =in=, =out=, =like=,
+// =between= and =isnull=. A '*' in the value of == and =like= matches any characters.
+// When allowedSelectors are given, comparisons on any other selector are rejected.
+func ParseRsql(expression string, allowedSelectors ...string) (map[string]any, error) {
+	parser := &rsqlParser{expression: expression}
+	if len(allowedSelectors) > 0 {
+		parser.allowed = make(map[string]bool)
+		for _, s := range allowedSelectors {
+			parser.allowed[s] = true
+		}
+	}
+	parser.skipSpaces()
+	if parser.eof() {
+		return nil, parser.fail("empty expression")
+	}
+	result, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	parser.skipSpaces()
+	if !parser.eof() {
+		return nil, parser.fail(fmt.Sprintf("unexpected '%c'", parser.peek()))
+	}
+	return result, nil
+}
+
+func (p *rsqlParser) eof() bool {
+	return p.position >= len(p.expression)
+}
+
+func (p *rsqlParser) peek() byte {
+	return p.expression[p.position]
+}
+
+func (p *rsqlParser) skipSpaces() {
+	for !p.eof() && p.peek() == ' ' {
+		p.position++
+	}
+}
+
+func (p *rsqlParser) fail(message string) *RsqlSyntaxError {
+	return &RsqlSyntaxError{Position: p.position, Message: message}
+}
+
+func (p *rsqlParser) parseOr() (map[string]any, error) {
+	return p.parseGroup(',', FindByOr, p.parseAnd)
+}
+
+func (p *rsqlParser) parseAnd() (map[string]any, error) {
+	return p.parseGroup(';', FindByAnd, p.parseTerm)
+}
+
+func (p *rsqlParser) parseGroup(separator byte, key string, parseItem func() (map[string]any, error)) (map[string]any, error) {
+	items := make([]map[string]any, 0, 1)
+	for {
+		item, err := parseItem()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.skipSpaces()
+		if p.eof() || p.peek() != separator {
+			break
+		}
+		p.position++
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return map[string]any{key: items}, nil
+}
+
+func (p *rsqlParser) parseTerm() (map[string]any, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return nil, p.fail("unexpected end of expression")
+	}
+	if p.peek() == '(' {
+		p.position++
+		result, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.eof() || p.peek() != ')' {
+			return nil, p.fail("missing ')'")
+		}
+		p.position++
+		return result, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *rsqlParser) parseComparison() (map[string]any, error) {
+	start := p.position
+	selector := p.readUnreserved()
+	if len(selector) == 0 {
+		return nil, p.fail("missing selector")
+	}
+	if p.allowed != nil && !p.allowed[selector] {
+		return nil, &RsqlSyntaxError{Position: start, Message: fmt.Sprintf("'%s' cannot be filtered", selector)}
+	}
+
+	p.skipSpaces()
+	operatorStart := p.position
+	operator, ok := p.readOperator()
+	if !ok {
+		return nil, p.fail("missing comparison operator")
+	}
+	filterOperator, ok := rsqlOperators[operator]
+	if !ok {
+		return nil, &RsqlSyntaxError{Position: operatorStart, Message: fmt.Sprintf("unknown operator '%s'", operator)}
+	}
+
+	p.skipSpaces()
+	argumentsStart := p.position
+	var arguments []string
+	var err error
+	if !p.eof() && p.peek() == '(' {
+		arguments, err = p.readArgumentList()
+	} else {
+		var argument string
+		argument, err = p.readArgument()
+		arguments = []string{argument}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	condition := Condition{Operator: filterOperator}
+	switch filterOperator {
+	case FilterIn, FilterNotIn, FilterBetween:
+		values := make([]any, len(arguments))
+		for i, a := range arguments {
+			values[i] = a
+		}
+		if filterOperator == FilterBetween && len(values) != 2 {
+			return nil, &RsqlSyntaxError{Position: argumentsStart, Message: "'=between=' expects two arguments"}
+		}
+		condition.Value = values
+	default:
+		if len(arguments) != 1 {
+			return nil, &RsqlSyntaxError{Position: argumentsStart, Message: fmt.Sprintf("'%s' expects a single argument", operator)}
+		}
+		condition.Value = arguments[0]
+		if filterOperator == FilterLike || (filterOperator == FilterEq && strings.Contains(arguments[0], "*")) {
+			// Only '*' is a wildcard, so the ones of LIKE are matched as they are
+			condition = Like(strings.ReplaceAll(likeEscaper.Replace(arguments[0]), "*", "%"))
+		}
+	}
+	return map[string]any{selector: condition}, nil
+}
+
+func (p *rsqlParser) readUnreserved() string {
+	start := p.position
+	for !p.eof() && !strings.ContainsRune(rsqlReserved, rune(p.peek())) {
+		p.position++
+	}
+	return p.expression[start:p.position]
+}
+
+func (p *rsqlParser) readOperator() (string, bool) {
+	if p.eof() {
+		return "", false
+	}
+	start := p.position
+	switch c := p.peek(); c {
+	case '<', '>', '!':
+		p.position++
+		if !p.eof() && p.peek() == '=' {
+			p.position++
+		} else if c == '!' {
+			p.position = start
+			return "", false
+		}
+		return p.expression[start:p.position], true
+	case '=':
+		p.position++
+		if !p.eof() && p.peek() == '=' {
+			p.position++
+			return "==", true
+		}
+		for !p.eof() && p.peek() >= 'a' && p.peek() <= 'z' {
+			p.position++
+		}
+		if p.eof() || p.peek() != '=' || p.position == start+1 {
+			p.position = start
+			return "", false
+		}
+		p.position++
+		return p.expression[start:p.position], true
+	}
+	return "", false
+}
+
+func (p *rsqlParser) readArgumentList() ([]string, error) {
+	p.position++
+	result := make([]string, 0)
+	for {
+		p.skipSpaces()
+		argument, err := p.readArgument()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, argument)
+		p.skipSpaces()
+		if p.eof() {
+			return nil, p.fail("missing ')'")
+		}
+		switch p.peek() {
+		case ',':
+			p.position++
+		case ')':
+			p.position++
+			return result, nil
+		default:
+			return nil, p.fail(fmt.Sprintf("unexpected '%c'", p.peek()))
+		}
+	}
+}
+
+func (p *rsqlParser) readArgument() (string, error) {
+	if p.eof() {
+		return "", p.fail("missing argument")
+	}
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		argument := p.readUnreserved()
+		if len(argument) == 0 {
+			return "", p.fail("missing argument")
+		}
+		return argument, nil
+	}
+	start := p.position
+	p.position++
+	var builder strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		p.position++
+		switch {
+		case c == '\\' && !p.eof():
+			builder.WriteByte(p.peek())
+			p.position++
+		case c == quote:
+			return builder.String(), nil
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return "", &RsqlSyntaxError{Position: start, Message: "unterminated quoted argument"}
+}
@@ -0,0 +1,88 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRsql(t *testing.T) {
+	result, err := ParseRsql("full_name=like=J*;(code=gt=5,email=out=(x,'y,z'))")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]any{
+		FindByAnd: []map[string]any{
+			{"full_name": Like("J%")},
+			{FindByOr: []map[string]any{
+				{"code": Gt("5")},
+				{"email": NotIn("x", "y,z")},
+			}},
+		},
+	}, result)
+
+	result, err = ParseRsql(`name=="John Smith",code<=3,code>2,code=between=(1,4),email=isnull=true,name!=J*`)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]any{
+		FindByOr: []map[string]any{
+			{"name": Eq("John Smith")},
+			{"code": Lte("3")},
+			{"code": Gt("2")},
+			{"code": Between("1", "4")},
+			{"email": Condition{Operator: FilterIsNull, Value: "true"}},
+			{"name": Ne("J*")},
+		},
+	}, result)
+
+	result, err = ParseRsql("name==J*")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]any{"name": Like("J%")}, result)
+}
+
+func TestParseRsqlErrors(t *testing.T) {
+	cases := map[string]int{
+		"":                       0,
+		"name":                   4,
+		"name=x=1":               4,
+		"name==":                 6,
+		"name==1;":               8,
+		"(name==1":               8,
+		"name==1)":               7,
+		"name=in=(1,2":           12,
+		"name=between=(1,2,3)":   13,
+		"name=gt=(1,2)":          8,
+		"name=='abc":             6,
+		"name==1;secret==2":      8,
+		"name==1;(code==1,x==2)": 17,
+	}
+	for expression, position := range cases {
+		_, err := ParseRsql(expression, "name", "code")
+		assert.ErrorIs(t, err, ErrInvalidFilter, expression)
+		var syntaxErr *RsqlSyntaxError
+		assert.True(t, errors.As(err, &syntaxErr), expression)
+		assert.Equal(t, position, syntaxErr.Position, expression)
+	}
+}
+
+func TestFindByRsql(t *testing.T) {
+	create_and_populate_coded_test_db()
+	findBy, err := ParseRsql("full_name=like=Cont-1*;(code=gt=15,email=isnull=true)")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, findByCount(t, findBy))
+}
+
+func TestRsqlLikeEscapesWildcards(t *testing.T) {
+	create_and_populate_coded_test_db()
+	contactsService.Create(&TestContact{FullName: "50%_off!"})
+	for expression, count := range map[string]int{
+		"full_name==Cont-1*":     11,
+		"full_name==Cont_1*":     0,
+		"full_name=like=Cont-1%": 0,
+		"full_name=='50%_off!*'": 1,
+		"full_name==50*":         1,
+		"full_name==5_%*":        0,
+	} {
+		findBy, err := ParseRsql(expression)
+		assert.Nil(t, err, expression)
+		assert.Equal(t, count, findByCount(t, findBy), expression)
+	}
+}
@@ -0,0 +1,137 @@
+package crud
+
+import (
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+// softDeleteField returns the gorm.DeletedAt field of the given schema, if any.
+func softDeleteField(sch *schema.Schema) *schema.Field {
+	for _, field := range sch.Fields {
+		if field.FieldType == deletedAtType && len(field.DBName) > 0 {
+			return field
+		}
+	}
+	return nil
+}
+
+// getSoftDeleteColumn returns the column soft deleted entities are marked in,
+// or an ErrInvalidFilter if the service does not soft delete.
+func (service *CrudServiceImpl[T, TPublicId]) getSoftDeleteColumn() (clause.Column, error) {
+	sch, err := service.GetSchema()
+	if err != nil {
+		return clause.Column{}, err
+	}
+	field := softDeleteField(sch)
+	if field == nil || service._options.DisableSoftDelete {
+		return clause.Column{}, NewCrudError(ErrInvalidFilter, "soft delete is not enabled")
+	}
+	return clause.Column{Table: sch.Table, Name: field.DBName}, nil
+}
+
+// deleteScope returns the given query scoped for deletes: permanent ones if soft delete is disabled.
+func (service *CrudServiceImpl[T, TPublicId]) deleteScope(tx *gorm.DB) *gorm.DB {
+	if service._options.DisableSoftDelete {
+		return tx.Unscoped()
+	}
+	return tx
+}
+
+// Restore undoes the soft delete of the entities with the given public ids, returning the number of restored ones.
+func (service *CrudServiceImpl[T, TPublicId]) Restore(publicIds []TPublicId) (int, error) {
+	column, err := service.getSoftDeleteColumn()
+	if err != nil {
+		return 0, err
+	}
+	if len(publicIds) == 0 {
+		return 0, nil
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+	deleted := func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped().Model(new(T)).
+			Where(service._options.PublicIdColumnName+" in ?", publicIds).
+			Where("? IS NOT NULL", column)
+	}
+	rowsAffected := 0
+	err = service.audited(db, func(tx *gorm.DB) error {
+		var before []T
+		if service.isAudited() {
+			if err := deleted(tx).Find(&before).Error; err != nil {
+				return err
+			}
+		}
+		db_result := deleted(tx).Update(column.Name, nil)
+		if db_result.Error != nil {
+			return db_result.Error
+		}
+		rowsAffected = int(db_result.RowsAffected)
+		if len(before) == 0 {
+			return nil
+		}
+		after, err := service.findByPrimaryKeys(tx, before)
+		if err != nil {
+			return err
+		}
+		return service.recordAudit(tx, OpRestore, before, after)
+	})
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return rowsAffected, nil
+}
+
+// ListDeleted returns the page of the soft deleted entities.
+func (service *CrudServiceImpl[T, TPublicId]) ListDeleted(filter ...*DataFilter) (*PagedList[T], error) {
+	column, err := service.getSoftDeleteColumn()
+	if err != nil {
+		return nil, err
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+	var pageFilter *DataFilter
+	if len(filter) > 0 {
+		pageFilter = filter[0]
+	}
+	return service.findPaged(db.Unscoped().Model(new(T)).Where("? IS NOT NULL", column), pageFilter)
+}
+
+// PurgeDeleted permanently deletes the entities soft deleted more than olderThan ago, all of them if it is 0.
+// It returns the number of purged entities.
+func (service *CrudServiceImpl[T, TPublicId]) PurgeDeleted(olderThan time.Duration) (int, error) {
+	column, err := service.getSoftDeleteColumn()
+	if err != nil {
+		return 0, err
+	}
+	db, cancel := service.getDb()
+	defer cancel()
+	cutoff := time.Now().Add(-olderThan)
+	rowsAffected := 0
+	err = service.audited(db, func(tx *gorm.DB) error {
+		purged := func(tx *gorm.DB) *gorm.DB {
+			return tx.Unscoped().Where("? IS NOT NULL AND ? <= ?", column, column, cutoff)
+		}
+		var before []T
+		if service.isAudited() {
+			if err := purged(tx.Model(new(T))).Find(&before).Error; err != nil {
+				return err
+			}
+		}
+		db_result := purged(tx).Delete(new(T))
+		if db_result.Error != nil {
+			return db_result.Error
+		}
+		rowsAffected = int(db_result.RowsAffected)
+		return service.recordAudit(tx, OpPurge, before, nil)
+	})
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,131 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type TestNote struct {
+	Id        int
+	PublicId  string `gorm:"index:idx_test_notes_public_id,unique"`
+	Text      string
+	DeletedAt gorm.DeletedAt
+}
+
+func create_soft_delete_test_service(options *CrudServiceOptions[TestNote, string]) ExtendedCrudService[TestNote, string] {
+	create_and_populate_test_db(0)
+	crud_test_db.AutoMigrate(&TestNote{})
+	AutoMigrateAuditTable(crud_test_db, "test_audit_log")
+	service := NewCrudService(crud_test_db,
+		func(t TestNote) string { return t.PublicId },
+		func(t *TestNote, s string) { t.PublicId = s },
+		options,
+	)
+	for _, text := range []string{"Note-0", "Note-1", "Note-2", "Note-3"} {
+		if _, err := service.Create(&TestNote{Text: text}); err != nil {
+			panic(err)
+		}
+	}
+	return service
+}
+
+func textsOf(notes []TestNote) []string {
+	result := make([]string, len(notes))
+	for i, note := range notes {
+		result[i] = note.Text
+	}
+	return result
+}
+
+func TestSoftDelete(t *testing.T) {
+	service := create_soft_delete_test_service(nil)
+	deleted, err := service.DeleteWhere("text in ?", []string{"Note-1", "Note-2"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, deleted)
+
+	count, _ := service.Count()
+	assert.Equal(t, 2, count)
+	var stored int64
+	crud_test_db.Unscoped().Model(&TestNote{}).Count(&stored)
+	assert.Equal(t, int64(4), stored)
+
+	trash, err := service.ListDeleted(&DataFilter{Sort: "text", Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, trash.TotalCount)
+	assert.Equal(t, []string{"Note-1", "Note-2"}, textsOf(trash.List))
+	assert.True(t, trash.List[0].DeletedAt.Valid)
+
+	restored, err := service.Restore([]string{trash.List[0].PublicId, "unknown"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, restored)
+	// Restoring entities that are not deleted does nothing
+	restored, err = service.Restore([]string{trash.List[0].PublicId})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, restored)
+
+	all, _ := service.GetAll(&DataFilter{Sort: "text", Limit: 10})
+	assert.Equal(t, []string{"Note-0", "Note-1", "Note-3"}, textsOf(all.List))
+	trash, _ = service.ListDeleted()
+	assert.Equal(t, []string{"Note-2"}, textsOf(trash.List))
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	service := create_soft_delete_test_service(nil)
+	service.DeleteWhere("text in ?", []string{"Note-1", "Note-2"})
+	crud_test_db.Unscoped().Model(&TestNote{}).Where("text = ?", "Note-1").Update("deleted_at", time.Now().Add(-48*time.Hour))
+
+	purged, err := service.PurgeDeleted(24 * time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+	trash, _ := service.ListDeleted()
+	assert.Equal(t, []string{"Note-2"}, textsOf(trash.List))
+
+	purged, err = service.PurgeDeleted(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+	var stored int64
+	crud_test_db.Unscoped().Model(&TestNote{}).Count(&stored)
+	assert.Equal(t, int64(2), stored)
+}
+
+func TestSoftDeleteDisabled(t *testing.T) {
+	service := create_soft_delete_test_service(&CrudServiceOptions[TestNote, string]{DisableSoftDelete: true})
+	deleted, err := service.DeleteWhere("text = ?", "Note-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, deleted)
+	var stored int64
+	crud_test_db.Unscoped().Model(&TestNote{}).Count(&stored)
+	assert.Equal(t, int64(3), stored)
+
+	_, err = service.ListDeleted()
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	_, err = service.Restore([]string{"any"})
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+	_, err = service.PurgeDeleted(0)
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+
+	// Entities without a gorm.DeletedAt field cannot be soft deleted
+	_, err = contactsService.ListDeleted()
+	assert.True(t, errors.Is(err, ErrInvalidFilter))
+}
+
+func TestSoftDeleteAudit(t *testing.T) {
+	service := create_soft_delete_test_service(&CrudServiceOptions[TestNote, string]{AuditTable: "test_audit_log"})
+	note, _ := service.Create(&TestNote{Text: "Audited"})
+	service.DeleteByPublicId(note.PublicId)
+	service.Restore([]string{note.PublicId})
+	service.DeleteByPublicId(note.PublicId)
+	service.PurgeDeleted(0)
+
+	history, err := service.History(note.PublicId)
+	assert.Nil(t, err)
+	operations := make([]Operation, len(history.List))
+	for i, entry := range history.List {
+		operations[i] = entry.Operation
+	}
+	assert.Equal(t, []Operation{OpPurge, OpDelete, OpRestore, OpDelete, OpCreate}, operations)
+}
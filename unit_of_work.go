@@ -0,0 +1,26 @@
+package crud
+
+import "gorm.io/gorm"
+
+// UnitOfWork is a database transaction shared by several CRUD services.
+type UnitOfWork struct {
+	_tx *gorm.DB
+}
+
+// Tx returns the underlying transaction of the unit of work.
+func (uow *UnitOfWork) Tx() *gorm.DB {
+	return uow._tx
+}
+
+// RunInTransaction runs fn within a single transaction, committing when fn returns nil
+// and rolling back otherwise.
+func RunInTransaction(db *gorm.DB, fn func(uow *UnitOfWork) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(&UnitOfWork{_tx: tx})
+	})
+}
+
+// Use returns the given service bound to the transaction of the unit of work.
+func Use[T any, TPublicId any](uow *UnitOfWork, service ExtendedCrudService[T, TPublicId]) ExtendedCrudService[T, TPublicId] {
+	return service.WithTx(uow._tx)
+}
@@ -0,0 +1,59 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInTransactionCommits(t *testing.T) {
+	create_and_populate_test_db(30)
+	err := RunInTransaction(crud_test_db, func(uow *UnitOfWork) error {
+		if _, err := Use(uow, contactsService).Create(&TestContact{FullName: "NewCont"}); err != nil {
+			return err
+		}
+		_, err := Use(uow, tagsService).Create(&TestTag{Name: "friends"})
+		return err
+	})
+	assert.Nil(t, err)
+
+	contactsCount, _ := contactsService.Count()
+	tagsCount, _ := tagsService.Count()
+	assert.Equal(t, 31, contactsCount)
+	assert.Equal(t, 1, tagsCount)
+}
+
+func TestRunInTransactionRollsBack(t *testing.T) {
+	create_and_populate_test_db(30)
+	failure := errors.New("failure")
+	err := RunInTransaction(crud_test_db, func(uow *UnitOfWork) error {
+		if _, err := Use(uow, contactsService).Create(&TestContact{FullName: "NewCont"}); err != nil {
+			return err
+		}
+		if _, err := Use(uow, tagsService).Create(&TestTag{Name: "friends"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	contactsCount, _ := contactsService.Count()
+	tagsCount, _ := tagsService.Count()
+	assert.Equal(t, 30, contactsCount)
+	assert.Equal(t, 0, tagsCount)
+}
+
+func TestWithTx(t *testing.T) {
+	create_and_populate_test_db(30)
+	tx := crud_test_db.Begin()
+	_, err := contactsService.WithTx(tx).DeleteWhere("full_name like ?", "Cont-1%")
+	assert.Nil(t, err)
+
+	count, _ := contactsService.WithTx(tx).Count()
+	assert.Equal(t, 19, count)
+
+	tx.Rollback()
+	count, _ = contactsService.Count()
+	assert.Equal(t, 30, count)
+}
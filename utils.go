@@ -2,6 +2,8 @@ package crud
 
 import (
 	cryptoRand "crypto/rand"
+	"encoding"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"strconv"
@@ -67,48 +69,62 @@ func (service *DefaultIdGenerator[T]) GetNewId() T {
 	}
 }
 
+// Parse converts the given string into T, returning the zero value of T if it cannot be parsed.
 func Parse[T any](str string) T {
-	var val any = *new(T)
-	if _, ok := val.(string); ok {
+	val, _ := TryParse[T](str)
+	return val
+}
+
+// TryParse converts the given string into T. Besides strings, integers and booleans,
+// any T whose pointer implements encoding.TextUnmarshaler is supported.
+func TryParse[T any](str string) (T, error) {
+	var result T
+	var val any = result
+	var err error
+	switch val.(type) {
+	case string:
 		val = str
-		return val.(T)
-	} else if _, ok := val.(int32); ok {
-		parsedInt64, _ := strconv.ParseInt(str, 10, 32)
-		val = int32(parsedInt64)
-		return val.(T)
-	} else if _, ok := val.(int64); ok {
-		parsedInt64, _ := strconv.ParseInt(str, 10, 64)
-		val = parsedInt64
-		return val.(T)
-	} else if _, ok := val.(int16); ok {
-		parsedInt64, _ := strconv.ParseInt(str, 10, 16)
-		val = int16(parsedInt64)
-		return val.(T)
-	} else if _, ok := val.(int8); ok {
-		parsedInt64, _ := strconv.ParseInt(str, 10, 8)
-		val = int8(parsedInt64)
-		return val.(T)
-	} else if _, ok := val.(uint32); ok {
-		parsedInt64, _ := strconv.ParseUint(str, 10, 32)
-		val = uint32(parsedInt64)
-		return val.(T)
-	} else if _, ok := val.(uint64); ok {
-		parsedInt64, _ := strconv.ParseUint(str, 10, 64)
-		val = parsedInt64
-		return val.(T)
-	} else if _, ok := val.(uint16); ok {
-		parsedInt64, _ := strconv.ParseUint(str, 10, 16)
-		val = uint16(parsedInt64)
-		return val.(T)
-	} else if _, ok := val.(uint8); ok {
-		parsedInt64, _ := strconv.ParseUint(str, 10, 8)
-		val = uint8(parsedInt64)
-		return val.(T)
-	} else if _, ok := val.(bool); ok {
-		parsedBool, _ := strconv.ParseBool(str)
-		val = parsedBool
-		return val.(T)
-	} else {
-		return *new(T)
+	case int:
+		parsed, parseErr := strconv.ParseInt(str, 10, 0)
+		val, err = int(parsed), parseErr
+	case int64:
+		val, err = strconv.ParseInt(str, 10, 64)
+	case int32:
+		parsed, parseErr := strconv.ParseInt(str, 10, 32)
+		val, err = int32(parsed), parseErr
+	case int16:
+		parsed, parseErr := strconv.ParseInt(str, 10, 16)
+		val, err = int16(parsed), parseErr
+	case int8:
+		parsed, parseErr := strconv.ParseInt(str, 10, 8)
+		val, err = int8(parsed), parseErr
+	case uint:
+		parsed, parseErr := strconv.ParseUint(str, 10, 0)
+		val, err = uint(parsed), parseErr
+	case uint64:
+		val, err = strconv.ParseUint(str, 10, 64)
+	case uint32:
+		parsed, parseErr := strconv.ParseUint(str, 10, 32)
+		val, err = uint32(parsed), parseErr
+	case uint16:
+		parsed, parseErr := strconv.ParseUint(str, 10, 16)
+		val, err = uint16(parsed), parseErr
+	case uint8:
+		parsed, parseErr := strconv.ParseUint(str, 10, 8)
+		val, err = uint8(parsed), parseErr
+	case bool:
+		val, err = strconv.ParseBool(str)
+	default:
+		if unmarshaler, ok := any(&result).(encoding.TextUnmarshaler); ok {
+			if err := unmarshaler.UnmarshalText([]byte(str)); err != nil {
+				return *new(T), &CrudError{Kind: ErrInvalidId, Message: fmt.Sprintf("invalid id '%s'", str), Err: err}
+			}
+			return result, nil
+		}
+		return result, NewCrudError(ErrInvalidId, fmt.Sprintf("unsupported id type %T", result))
+	}
+	if err != nil {
+		return *new(T), &CrudError{Kind: ErrInvalidId, Message: fmt.Sprintf("invalid id '%s'", str), Err: err}
 	}
+	return val.(T), nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,4 +46,20 @@ func TestParse(t *testing.T) {
 
 	parsedInvalid := Parse[int32]("invalid_number")
 	assert.Equal(t, int32(0), parsedInvalid)
-}
\ No newline at end of file
+}
+
+func TestTryParse(t *testing.T) {
+	parsedInt, err := TryParse[int]("34")
+	assert.Nil(t, err)
+	assert.Equal(t, 34, parsedInt)
+
+	_, err = TryParse[int32]("invalid_number")
+	assert.ErrorIs(t, err, ErrInvalidId)
+
+	parsedUuid, err := TryParse[uuid.UUID]("e61bc045-f55b-4390-ac22-cb83734561ed")
+	assert.Nil(t, err)
+	assert.Equal(t, "e61bc045-f55b-4390-ac22-cb83734561ed", parsedUuid.String())
+
+	_, err = TryParse[uuid.UUID]("invalid_uuid")
+	assert.ErrorIs(t, err, ErrInvalidId)
+}
@@ -0,0 +1,145 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Operation is a kind of write done by a CRUD service.
+type Operation string
+
+const (
+	OpCreate Operation = "create"
+	OpUpdate Operation = "update"
+	OpDelete Operation = "delete"
+	// OpRestore and OpPurge are the audited restores and permanent deletes of soft deleted entities.
+	OpRestore Operation = "restore"
+	OpPurge   Operation = "purge"
+)
+
+// ValidateFunc checks an entity before it is written by the given operation.
+// Returning a validation error made by NewValidationError reports problems of individual fields.
+type ValidateFunc[T any] func(ctx context.Context, op Operation, entity *T) error
+
+// NewValidationError returns an ErrValidation error with the given field errors.
+func NewValidationError(fields ...FieldError) *CrudError {
+	return &CrudError{Kind: ErrValidation, Message: "validation failed", Fields: fields}
+}
+
+var defaultStructValidator *validator.Validate
+var defaultStructValidatorOnce sync.Once
+
+// GetDefaultStructValidator returns the validator used for `validate` struct tags
+// when no StructValidator is given in the options. Fields are named after their JSON names.
+func GetDefaultStructValidator() *validator.Validate {
+	defaultStructValidatorOnce.Do(func() {
+		defaultStructValidator = validator.New()
+		defaultStructValidator.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if len(name) == 0 {
+				return field.Name
+			}
+			return name
+		})
+	})
+	return defaultStructValidator
+}
+
+func toFieldErrors(index int, err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		result := make([]FieldError, 0, len(validationErrors))
+		for _, e := range validationErrors {
+			field := e.Namespace()
+			if i := strings.Index(field, "."); i >= 0 {
+				field = field[i+1:]
+			}
+			rule := e.Tag()
+			if len(e.Param()) > 0 {
+				rule += "=" + e.Param()
+			}
+			result = append(result, FieldError{
+				Index:   index,
+				Field:   field,
+				Message: fmt.Sprintf("failed on the '%s' rule", rule),
+			})
+		}
+		return result
+	}
+	var crudErr *CrudError
+	if errors.As(err, &crudErr) && len(crudErr.Fields) > 0 {
+		result := make([]FieldError, 0, len(crudErr.Fields))
+		for _, f := range crudErr.Fields {
+			f.Index = index
+			result = append(result, f)
+		}
+		return result
+	}
+	return []FieldError{{Index: index, Message: err.Error()}}
+}
+
+// setFields returns the names of the exported fields of the given entity that are not zero,
+// i.e. the ones a partial update writes.
+func setFields(entity any) []string {
+	value := reflect.Indirect(reflect.ValueOf(entity))
+	result := make([]string, 0, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).IsExported() && !value.Field(i).IsZero() {
+			result = append(result, value.Type().Field(i).Name)
+		}
+	}
+	return result
+}
+
+// Validate checks all the given entities for the given operation, using both the `validate` struct tags
+// and the configured Validators, and returns a single validation error with the problems of every entity.
+// As updates skip zero fields, the struct tags of those are not checked on OpUpdate.
+// Errors of the Validators other than validation errors are returned as they are.
+func (service *CrudServiceImpl[T, TPublicId]) Validate(op Operation, entities []T) error {
+	var structValidator *validator.Validate
+	if !service._options.DisableStructValidation {
+		structValidator = service._options.StructValidator
+		if structValidator == nil {
+			structValidator = GetDefaultStructValidator()
+		}
+	}
+	fields := make([]FieldError, 0)
+	for i := range entities {
+		if structValidator != nil {
+			var err error
+			if op == OpUpdate {
+				err = structValidator.StructPartialCtx(service._ctx, &entities[i], setFields(&entities[i])...)
+			} else {
+				err = structValidator.StructCtx(service._ctx, &entities[i])
+			}
+			if err != nil {
+				var invalidErr *validator.InvalidValidationError
+				if errors.As(err, &invalidErr) {
+					return err
+				}
+				fields = append(fields, toFieldErrors(i, err)...)
+			}
+		}
+		for _, validate := range service._options.Validators {
+			if err := validate(service._ctx, op, &entities[i]); err != nil {
+				if !errors.Is(err, ErrValidation) {
+					return err
+				}
+				fields = append(fields, toFieldErrors(i, err)...)
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return NewValidationError(fields...)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAllValidatesStructTags(t *testing.T) {
+	create_and_populate_test_db(30)
+	result, err := contactsService.CreateAll([]TestContact{
+		{FullName: "NewCont", Email: "test@mail.com"},
+		{FullName: "", Email: "not-an-email"},
+		{FullName: "NewCont3", Code: -1},
+	})
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Nil(t, result)
+
+	var crudErr *CrudError
+	assert.True(t, errors.As(err, &crudErr))
+	assert.Equal(t, []FieldError{
+		{Index: 1, Field: "FullName", Message: "failed on the 'required' rule"},
+		{Index: 1, Field: "Email", Message: "failed on the 'email' rule"},
+		{Index: 2, Field: "Code", Message: "failed on the 'gte=0' rule"},
+	}, crudErr.Fields)
+
+	count, _ := contactsService.Count()
+	assert.Equal(t, 30, count)
+}
+
+func TestValidatorFuncs(t *testing.T) {
+	create_and_populate_test_db(30)
+	var operations []Operation
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{
+			Validators: []ValidateFunc[TestContact]{
+				func(ctx context.Context, op Operation, c *TestContact) error {
+					operations = append(operations, op)
+					if strings.HasSuffix(c.Email, "@spam.com") {
+						return NewValidationError(FieldError{Field: "Email", Message: "spam domain"})
+					}
+					if c.Code == 13 {
+						return NewCrudError(ErrValidation, "unlucky code")
+					}
+					if c.Code == 99 {
+						return errors.New("connection lost")
+					}
+					return nil
+				},
+			},
+		},
+	)
+
+	_, err := service.CreateAll([]TestContact{
+		{FullName: "NewCont", Email: "a@spam.com"},
+		{FullName: "NewCont2", Code: 13},
+	})
+	var crudErr *CrudError
+	assert.True(t, errors.As(err, &crudErr))
+	assert.Equal(t, []FieldError{
+		{Index: 0, Field: "Email", Message: "spam domain"},
+		{Index: 1, Message: "unlucky code"},
+	}, crudErr.Fields)
+
+	entity, _ := service.FindOneByPublicId(crud_test_public_ids[0])
+	entity.Code = 13
+	rowsAffected, err := service.Update(entity)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Zero(t, rowsAffected)
+	assert.Equal(t, []Operation{OpCreate, OpCreate, OpUpdate}, operations)
+
+	// Other errors are not validation failures
+	_, err = service.Create(&TestContact{FullName: "NewCont", Code: 99})
+	assert.NotErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "connection lost", err.Error())
+}
+
+func TestUpdateValidatesSetFields(t *testing.T) {
+	create_and_populate_test_db(30)
+	// FullName is required, but left as it is by updates that do not set it
+	rowsAffected, err := contactsService.Update(&TestContact{PublicId: crud_test_public_ids[0], Phone: "555"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, rowsAffected)
+
+	_, err = contactsService.Update(&TestContact{PublicId: crud_test_public_ids[0], Email: "not-an-email"})
+	var crudErr *CrudError
+	assert.True(t, errors.As(err, &crudErr))
+	assert.Equal(t, []FieldError{{Index: 0, Field: "Email", Message: "failed on the 'email' rule"}}, crudErr.Fields)
+}
+
+func TestUpdateWhereValidatesSetFields(t *testing.T) {
+	create_and_populate_test_db(30)
+	rowsAffected, err := contactsService.UpdateWhere(&TestContact{Phone: "555"}, "full_name like ?", "Cont-1%")
+	assert.Nil(t, err)
+	assert.Equal(t, 11, rowsAffected)
+
+	rowsAffected, err = contactsService.UpdateWhere(&TestContact{Code: -1}, "full_name like ?", "Cont-1%")
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, 0, rowsAffected)
+	count, _ := contactsService.CountWhere("code = ?", -1)
+	assert.Equal(t, 0, count)
+
+	_, err = contactsService.UpdateWhere(nil, "full_name like ?", "Cont-1%")
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestDisableStructValidation(t *testing.T) {
+	create_and_populate_test_db(30)
+	service := NewCrudService(crud_test_db,
+		func(t TestContact) string { return t.PublicId },
+		func(t *TestContact, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestContact, string]{DisableStructValidation: true},
+	)
+	_, err := service.Create(&TestContact{Email: "not-an-email"})
+	assert.Nil(t, err)
+}
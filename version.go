@@ -0,0 +1,108 @@
+package crud
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// isIntegerVersion tells whether the given version field is an integer, rather than a time.
+func isIntegerVersion(field *schema.Field) bool {
+	switch field.FieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// getVersionField returns the field of the VersionColumnName, nil if the service is not versioned.
+func (service *CrudServiceImpl[T, TPublicId]) getVersionField() (*schema.Field, error) {
+	if len(service._options.VersionColumnName) == 0 {
+		return nil, nil
+	}
+	sch, err := service.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	field := lookUpColumn(sch, service._options.VersionColumnName)
+	if field == nil {
+		return nil, fmt.Errorf("version column '%s' is not a column of %s", service._options.VersionColumnName, sch.Name)
+	}
+	if !isIntegerVersion(field) && field.FieldType != timeType {
+		return nil, fmt.Errorf("version column '%s' of %s should be an integer or a time", service._options.VersionColumnName, sch.Name)
+	}
+	return field, nil
+}
+
+// initVersion sets the integer version of the given new entity to 1, unless it is set.
+func initVersion[T any](db *gorm.DB, field *schema.Field, entity *T) error {
+	if field == nil || !isIntegerVersion(field) {
+		return nil
+	}
+	value := reflect.ValueOf(entity).Elem()
+	if _, isZero := field.ValueOf(db.Statement.Context, value); !isZero {
+		return nil
+	}
+	return field.Set(db.Statement.Context, value, 1)
+}
+
+// nextVersion sets the version of the given entity to the one following it and returns the version it had.
+// Integer versions are incremented, time versions are set to the current time.
+func nextVersion[T any](db *gorm.DB, field *schema.Field, entity *T) (any, error) {
+	value := reflect.ValueOf(entity).Elem()
+	current, _ := field.ValueOf(db.Statement.Context, value)
+	var next any = db.NowFunc()
+	if isIntegerVersion(field) {
+		next = reflect.ValueOf(current).Convert(reflect.TypeOf(int64(0))).Int() + 1
+	}
+	return current, field.Set(db.Statement.Context, value, next)
+}
+
+// bumpVersions increments, or sets to the current time, the versions of the entities matching the given query.
+func bumpVersions(query *gorm.DB, field *schema.Field) error {
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	var next any = query.NowFunc()
+	if isIntegerVersion(field) {
+		next = gorm.Expr("? + 1", column)
+	}
+	return query.UpdateColumn(field.DBName, next).Error
+}
+
+// readVersion returns the version of the entity with the given public id, nil if there isn't any.
+func (service *CrudServiceImpl[T, TPublicId]) readVersion(tx *gorm.DB, field *schema.Field, publicId TPublicId) (any, error) {
+	var found []T
+	err := tx.Model(new(T)).
+		Select(field.DBName).
+		Where(service._options.PublicIdColumnName+" = ?", publicId).
+		Limit(1).
+		Find(&found).Error
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	version, _ := field.ValueOf(tx.Statement.Context, reflect.ValueOf(&found[0]).Elem())
+	return version, nil
+}
+
+// checkVersion fails with a VersionConflictError if the versioned update of the given entity matched no row
+// although the entity exists. Otherwise, time versions are read again, as the database may have rounded them.
+func (service *CrudServiceImpl[T, TPublicId]) checkVersion(tx *gorm.DB, field *schema.Field, entity *T, rowsAffected int64) error {
+	if rowsAffected > 0 && isIntegerVersion(field) {
+		return nil
+	}
+	publicId := service.GetPublicId(*entity)
+	version, err := service.readVersion(tx, field, publicId)
+	if err != nil || version == nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return &VersionConflictError{PublicId: publicId, CurrentVersion: version}
+	}
+	return field.Set(tx.Statement.Context, reflect.ValueOf(entity).Elem(), version)
+}
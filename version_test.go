@@ -0,0 +1,127 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestDocument struct {
+	Id        int
+	PublicId  string `gorm:"index:idx_test_documents_public_id,unique"`
+	Title     string
+	Version   int
+	UpdatedAt time.Time
+}
+
+func create_version_test_service(versionColumn string) ExtendedCrudService[TestDocument, string] {
+	create_and_populate_test_db(0)
+	crud_test_db.AutoMigrate(&TestDocument{})
+	return NewCrudService(crud_test_db,
+		func(t TestDocument) string { return t.PublicId },
+		func(t *TestDocument, s string) { t.PublicId = s },
+		&CrudServiceOptions[TestDocument, string]{VersionColumnName: versionColumn},
+	)
+}
+
+func TestVersionedUpdate(t *testing.T) {
+	service := create_version_test_service("version")
+	doc, err := service.Create(&TestDocument{Title: "Draft"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, doc.Version)
+
+	first, second := *doc, *doc
+	first.Title = "First"
+	updated, err := service.Update(&first)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, 2, first.Version)
+
+	// The second update is based on the version before the first one
+	second.Title = "Second"
+	_, err = service.Update(&second)
+	assert.ErrorIs(t, err, ErrConflict)
+	var conflictErr *VersionConflictError
+	assert.True(t, errors.As(err, &conflictErr))
+	assert.Equal(t, doc.PublicId, conflictErr.PublicId)
+	assert.Equal(t, 2, conflictErr.CurrentVersion)
+	assert.Equal(t, 1, second.Version)
+
+	stored, _ := service.FindOneByPublicId(doc.PublicId)
+	assert.Equal(t, "First", stored.Title)
+	assert.Equal(t, 2, stored.Version)
+
+	// Unknown entities are not conflicts
+	updated, err = service.Update(&TestDocument{PublicId: "unknown", Title: "None", Version: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, updated)
+}
+
+func TestVersionedUpdateAll(t *testing.T) {
+	service := create_version_test_service("version")
+	docs, _ := service.CreateAll([]TestDocument{{Title: "Doc-1"}, {Title: "Doc-2", Version: 5}})
+	assert.Equal(t, []int{1, 5}, []int{docs[0].Version, docs[1].Version})
+
+	docs[0].Title = "Doc-1 v2"
+	docs[1].Title = "Doc-2 v6"
+	docs[1].Version = 4
+	_, err := service.UpdateAll(docs)
+	assert.ErrorIs(t, err, ErrConflict)
+	// Updates are all or nothing
+	stored, _ := service.FindOneByPublicId(docs[0].PublicId)
+	assert.Equal(t, "Doc-1", stored.Title)
+	assert.Equal(t, []int{1, 4}, []int{docs[0].Version, docs[1].Version})
+
+	docs[1].Version = 5
+	updated, err := service.UpdateAll(docs)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, updated)
+	assert.Equal(t, []int{2, 6}, []int{docs[0].Version, docs[1].Version})
+
+	// Bulk updates change the versions too
+	updated, err = service.UpdateWhere(&TestDocument{Title: "Archived"}, "title like ?", "Doc-%")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, updated)
+	stored, _ = service.FindOneByPublicId(docs[1].PublicId)
+	assert.Equal(t, "Archived", stored.Title)
+	assert.Equal(t, 7, stored.Version)
+
+	// A version set in the entity does not overwrite the new ones
+	_, err = service.UpdateWhere(&TestDocument{Title: "Restored", Version: 1}, "title = ?", "Archived")
+	assert.Nil(t, err)
+	stored, _ = service.FindOneByPublicId(docs[1].PublicId)
+	assert.Equal(t, "Restored", stored.Title)
+	assert.Equal(t, 8, stored.Version)
+}
+
+func TestTimeVersionedUpdate(t *testing.T) {
+	service := create_version_test_service("UpdatedAt")
+	doc, err := service.Create(&TestDocument{Title: "Draft"})
+	assert.Nil(t, err)
+	doc, _ = service.FindOneByPublicId(doc.PublicId)
+
+	stale := *doc
+	doc.Title = "First"
+	_, err = service.Update(doc)
+	assert.Nil(t, err)
+	assert.True(t, doc.UpdatedAt.After(stale.UpdatedAt))
+
+	doc.Title = "Second"
+	_, err = service.Update(doc)
+	assert.Nil(t, err)
+
+	stale.Title = "Stale"
+	_, err = service.Update(&stale)
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestInvalidVersionColumn(t *testing.T) {
+	service := create_version_test_service("title")
+	_, err := service.Create(&TestDocument{Title: "Draft"})
+	assert.NotNil(t, err)
+	service = create_version_test_service("unknown")
+	_, err = service.Update(&TestDocument{Title: "Draft"})
+	assert.NotNil(t, err)
+}